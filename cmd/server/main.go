@@ -2,22 +2,137 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/DoyleJ11/lol-draft-backend/internal/catalog"
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/formats"
 	"github.com/DoyleJ11/lol-draft-backend/internal/httpapi"
 	"github.com/DoyleJ11/lol-draft-backend/internal/hub"
+	"github.com/DoyleJ11/lol-draft-backend/internal/store"
 )
 
 func main() {
-	ctx := context.Background()
-	h := hub.NewHub(ctx)
+	// Custom draft formats (JSON/YAML) become selectable by ID on POST /lobbies
+	if dir := os.Getenv("DRAFT_FORMATS_DIR"); dir != "" {
+		custom, err := formats.LoadDir(dir)
+		if err != nil {
+			log.Fatalf("loading draft formats: %v", err)
+		}
+		for _, f := range custom {
+			if err := engine.RegisterFormat(f); err != nil {
+				log.Fatalf("registering draft format: %v", err)
+			}
+			log.Printf("loaded draft format %q (%d steps)", f.ID, len(f.Steps))
+		}
+	}
+
+	// Data Dragon champion.json; without it drafts use the engine's built-in list
+	var cat *catalog.Catalog
+	if path := os.Getenv("CHAMPION_CATALOG"); path != "" {
+		c, err := catalog.Load(path)
+		if err != nil {
+			log.Fatalf("loading champion catalog: %v", err)
+		}
+		cat = c
+		log.Printf("loaded %d champions from %s", len(cat.IDs()), path)
+	} else {
+		log.Println("CHAMPION_CATALOG not set; falling back to the built-in roster, which may be missing recent champions")
+	}
+
+	// Lobbies and their draft events; in memory only unless a database is set
+	var st store.Store = store.NewMemory()
+	if path := os.Getenv("DRAFT_DB_PATH"); path != "" {
+		db, err := store.OpenSQLite(path)
+		if err != nil {
+			log.Fatalf("opening database: %v", err)
+		}
+		defer db.Close()
+		st = db
+		log.Printf("persisting lobbies to %s", path)
+	} else {
+		log.Println("DRAFT_DB_PATH not set; lobbies are kept in memory only")
+	}
+
+	// Empty, finished and never-started lobbies are shut down after these
+	// (e.g. DRAFT_IDLE_TTL=10m; 0 keeps them forever)
+	gc := hub.DefaultGC
+	gc.Idle = envDuration("DRAFT_IDLE_TTL", gc.Idle)
+	gc.Completed = envDuration("DRAFT_COMPLETED_TTL", gc.Completed)
+	gc.Unstarted = envDuration("DRAFT_UNSTARTED_TTL", gc.Unstarted)
+
+	// Slow sockets: how long a pending update may wait before that counts as
+	// a missed deadline, and how many misses in a row drop the client (e.g.
+	// DRAFT_SEND_DEADLINE=5s DRAFT_MAX_MISSED=3; unset keeps 2s and 5)
+	sendDeadline := envDuration("DRAFT_SEND_DEADLINE", 0)
+	maxMissed := envInt("DRAFT_MAX_MISSED", 0)
+
+	opts := []hub.Option{hub.WithStore(st), hub.WithGC(gc), hub.WithBackpressure(sendDeadline, maxMissed)}
+	if cat != nil {
+		opts = append(opts, hub.WithChampions(cat))
+	}
+	h := hub.NewHub(context.Background(), opts...)
 
 	// Build the router *with* the hub injected
-	handler := httpapi.SetupRoutes(h)
+	handler := httpapi.SetupRoutes(h, cat)
+	srv := &http.Server{Addr: ":8080", Handler: handler}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Println("listening on :8080")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+	stop() // a second signal kills the process
 
-	log.Println("listening on :8080")
-	if err := http.ListenAndServe(":8080", handler); err != nil {
-		log.Fatal(err)
+	log.Println("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Lobbies first: the hub stops creating new ones, clients get a
+	// ServerShuttingDown notice, and closing each lobby closes its sockets
+	// (StatusGoingAway). srv.Shutdown doesn't track hijacked WebSocket
+	// connections, so the hub waits on those separately.
+	if err := h.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: lobbies: %v", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: http: %v", err)
+	}
+	if err := h.WaitConns(shutdownCtx); err != nil {
+		log.Printf("shutdown: websockets: %v", err)
+	}
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return d
+}
+
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Fatalf("%s: want a non-negative whole number, got %q", name, v)
 	}
+	return n
 }
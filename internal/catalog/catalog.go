@@ -0,0 +1,105 @@
+// Package catalog holds the champion roster the server validates drafts against.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+type Champion struct {
+	ID    string   `json:"id"`  // Data Dragon name key, e.g. "MonkeyKing"
+	Key   int      `json:"key"` // numeric ID the engine drafts with, e.g. 62
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Catalog is read-only once loaded, so it's safe to share between lobbies.
+type Catalog struct {
+	byKey map[int]Champion
+	all   []Champion // sorted by name
+}
+
+// Shape of Data Dragon's champion.json. Data Dragon has no "roles"; we accept
+// it as an optional extra so a curated file can carry lane info.
+type dataDragonFile struct {
+	Data map[string]struct {
+		ID    string   `json:"id"`
+		Key   string   `json:"key"`
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Roles []string `json:"roles"`
+	} `json:"data"`
+}
+
+func Load(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+func Parse(r io.Reader) (*Catalog, error) {
+	var file dataDragonFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{byKey: make(map[int]Champion, len(file.Data))}
+	for name, raw := range file.Data {
+		key, err := strconv.Atoi(raw.Key)
+		if err != nil || key <= 0 {
+			return nil, fmt.Errorf("champion %q: bad key %q", name, raw.Key)
+		}
+		if _, dup := c.byKey[key]; dup {
+			return nil, fmt.Errorf("champion %q: duplicate key %d", name, key)
+		}
+		c.byKey[key] = Champion{ID: raw.ID, Key: key, Name: raw.Name, Tags: raw.Tags, Roles: raw.Roles}
+	}
+	if len(c.byKey) == 0 {
+		return nil, fmt.Errorf("no champions in catalog")
+	}
+
+	for _, champ := range c.byKey {
+		c.all = append(c.all, champ)
+	}
+	sort.Slice(c.all, func(i, j int) bool { return c.all[i].Name < c.all[j].Name })
+	return c, nil
+}
+
+func (c *Catalog) Get(key int) (Champion, bool) {
+	champ, ok := c.byKey[key]
+	return champ, ok
+}
+
+// Has and IDs satisfy engine.Roster.
+func (c *Catalog) Has(key int) bool {
+	_, ok := c.byKey[key]
+	return ok
+}
+
+// IDs returns every champion key in ascending order.
+func (c *Catalog) IDs() []int {
+	ids := make([]int, 0, len(c.byKey))
+	for key := range c.byKey {
+		ids = append(ids, key)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// All returns the champions sorted by name. Callers must not modify the slice.
+func (c *Catalog) All() []Champion {
+	return c.all
+}
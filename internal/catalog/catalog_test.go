@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `{
+	"type": "champion",
+	"version": "14.1.1",
+	"data": {
+		"Aatrox": {"id": "Aatrox", "key": "266", "name": "Aatrox", "tags": ["Fighter", "Tank"], "roles": ["top"]},
+		"Ahri": {"id": "Ahri", "key": "103", "name": "Ahri", "tags": ["Mage", "Assassin"]},
+		"MonkeyKing": {"id": "MonkeyKing", "key": "62", "name": "Wukong", "tags": ["Fighter", "Tank"]}
+	}
+}`
+
+func TestParse_IndexesByNumericKey(t *testing.T) {
+	c, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if !c.Has(266) || c.Has(999999) || c.Has(0) {
+		t.Fatalf("Has: unexpected membership")
+	}
+	if got := c.IDs(); !reflect.DeepEqual(got, []int{62, 103, 266}) {
+		t.Fatalf("IDs: got %v", got)
+	}
+
+	wukong, ok := c.Get(62)
+	if !ok || wukong.ID != "MonkeyKing" || wukong.Name != "Wukong" {
+		t.Fatalf("Get(62): got %+v", wukong)
+	}
+
+	aatrox, _ := c.Get(266)
+	if !reflect.DeepEqual(aatrox.Roles, []string{"top"}) {
+		t.Fatalf("expected roles to be carried through, got %v", aatrox.Roles)
+	}
+
+	names := []string{}
+	for _, champ := range c.All() {
+		names = append(names, champ.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Aatrox", "Ahri", "Wukong"}) {
+		t.Fatalf("All: want name order, got %v", names)
+	}
+}
+
+func TestParse_RejectsBadKeys(t *testing.T) {
+	cases := []string{
+		`{"data": {"X": {"id": "X", "key": "abc", "name": "X"}}}`,
+		`{"data": {"X": {"id": "X", "key": "0", "name": "X"}}}`,
+		`{"data": {}}`,
+	}
+	for _, body := range cases {
+		if _, err := Parse(strings.NewReader(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
@@ -10,6 +10,20 @@ var ErrIllegalPick = errors.New("illegal champion")
 var ErrIllegalBan = errors.New("illegal ban")
 var ErrUnsupportedCommand = errors.New("unsupported command")
 var ErrGameAlreadyCompleted = errors.New("game already completed")
+var ErrUnknownChampion = errors.New("unknown champion")
+var ErrGameInProgress = errors.New("game still in progress")
+var ErrSeriesOver = errors.New("series already decided")
+var ErrInvalidTeam = errors.New("invalid team")
+var ErrDraftNotStarted = errors.New("draft has not started")
+var ErrDraftStarted = errors.New("draft already started")
+var ErrSeatTaken = errors.New("seat already taken")
+var ErrTeamFull = errors.New("team is full")
+var ErrNotCaptain = errors.New("only the team captain can do that")
+var ErrNotReady = errors.New("both captains must be ready")
+var ErrNotYourPick = errors.New("pick belongs to another seat")
+var ErrNotOnTeam = errors.New("seat is not on that team")
+var ErrNotTrading = errors.New("not in trade phase")
+var ErrIllegalTrade = errors.New("illegal trade")
 
 type Team string
 
@@ -28,10 +42,13 @@ const (
 type Phase string
 
 const (
+	PhaseLobby Phase = "lobby" // seats filling up
+	PhaseReady Phase = "ready" // both captains ready, waiting on StartGame
 	PhaseBan1  Phase = "ban1"
 	PhasePick1 Phase = "pick1"
 	PhaseBan2  Phase = "ban2"
 	PhasePick2 Phase = "pick2"
+	PhaseTrade Phase = "trade" // after the last pick, teammates may swap champions
 	PhaseDone  Phase = "done"
 )
 
@@ -45,15 +62,67 @@ type State struct {
 	Cursor   int
 	Picks    map[Team][]int
 	Bans     map[Team][]int
-	Fearless map[int]bool
+	Fearless map[Team][]int // champions each team played in earlier games of the series
 	Hover    map[string]int
 	Rules    Rules
+	Series   Series
+	Seed     int64 // per-lobby; makes timeout auto-picks reproducible
+
+	// Pre-draft: seats fill and captains ready up; no turns run until StartGame
+	PreDraft bool
+	Seats    map[Team][]Seat
+	Ready    map[Team]bool
+
+	// Post-draft trade window; open offers are cleared when it closes
+	Trading     bool
+	TradeOffers []TradeOffer
+
+	// Chess-clock reserve: ms each team has drained this game, and whether the
+	// team on turn is currently running on it
+	TimeBankUsed   map[Team]int
+	TimeBankActive bool
+
+	// Host froze the clock; PausedMs is what was left on it
+	Paused   bool
+	PausedMs int
 }
 
+// FearlessMode decides who is locked out of champions played earlier in a series.
+type FearlessMode string
+
+const (
+	FearlessOff  FearlessMode = "off"
+	FearlessHard FearlessMode = "hard" // nobody may pick it again
+	FearlessTeam FearlessMode = "team" // only the team that played it is locked out
+)
+
 type Rules struct {
-	Fearless     bool
-	PickTimerSec int
-	BanTimerSec  int
+	Fearless      FearlessMode // "" behaves like FearlessOff
+	PickTimerSec  int
+	BanTimerSec   int
+	Format        DraftFormat
+	TradeTimerSec int // 0 = no trade phase, the game completes on the last pick
+	TimeBankSec   int // per-team reserve per game once a turn timer runs out; 0 = none
+
+	// How far behind the live draft spectators' snapshots run, and whether
+	// they see hovers (players only ever see their own team's). The engine
+	// ignores both; the lobby builds each client's view.
+	SpectatorDelaySec int
+	SpectatorHovers   bool
+
+	// Champions picks and bans are checked against; nil means the built-in
+	// list (roster.go). Not part of the log: whoever creates or restores a
+	// lobby sets it.
+	Champions Roster `json:"-"`
+}
+
+// ActiveFormat is the format this draft runs on. Rules built without one
+// (tests, old callers) fall back to the standard tournament order.
+func (r Rules) ActiveFormat() DraftFormat {
+	if len(r.Format.Steps) == 0 {
+		return FormatTournament
+	}
+	return r.Format
 }
 
 type CommandType string
@@ -64,16 +133,43 @@ const (
 	CmdHoverChampion  CommandType = "HoverChampion"
 	CmdTimeoutAdvance CommandType = "TimeoutAdvance"
 	CmdStartGame      CommandType = "StartGame"
+	CmdStartNextGame  CommandType = "StartNextGame"
+	CmdJoinSeat       CommandType = "JoinSeat"
+	CmdReady          CommandType = "Ready"
+	CmdUnready        CommandType = "Unready"
+	CmdProposeTrade   CommandType = "ProposeTrade"
+	CmdAcceptTrade    CommandType = "AcceptTrade"
+	CmdDeclineTrade   CommandType = "DeclineTrade"
+	CmdStartTimeBank  CommandType = "StartTimeBank"
+	CmdPauseDraft     CommandType = "PauseDraft"
+	CmdResumeDraft    CommandType = "ResumeDraft"
 )
 
 /*
 	CmdLockPick      -> EvtChampionPicked -> EvtTurnAdvanced -> EvtTimerStarted
     CmdBanChampion    -> EvtChampionBanned -> EvtTurnAdvanced -> EvtTimerStarted
-    CmdHoverChampion  -> I don't think we have an event for hovering a champion because I believe hovers are meant to be in memory, not persistent
+    CmdHoverChampion  -> EvtChampionHovered (logged too, so a rebuilt lobby shows the same hovers)
     CmdTimeoutAdvance  -> EvtTimerExpired-> EvtChampionPicked -> EvtTurnAdvanced or EvtGameCompleted
 	^ My logic here is that we send the event that the timer expires, then we lock in either a random or hovered champion (EvtChampionPicked),
-	then we advance the turn
-    CmdStartGame      -> EvtTimerStarted
+	then we advance the turn. A ban turn with nothing hovered is EvtBanSkipped -> EvtTurnAdvanced
+    (lobby creation) -> EvtLobbyCreated{Initial}: first entry of every lobby log, so Reduce needs nothing else
+    CmdJoinSeat       -> EvtSeatJoined (pre-draft only; first seat on a team is its captain)
+	^ seats get their pick slots when the draft starts; LockPick must come from the slot's seat
+    CmdReady/Unready  -> EvtTeamReady / EvtTeamUnready (captain only)
+    CmdStartGame      -> EvtDraftStarted -> EvtTimerStarted (needs both captains ready)
+    Last pick         -> EvtTradePhaseStarted instead of EvtGameCompleted when Rules.TradeTimerSec > 0
+    CmdProposeTrade   -> EvtTradeProposed (SeatID offers to swap with TargetSeatID, same team)
+    CmdAcceptTrade    -> EvtTradeAccepted (SeatID accepts TargetSeatID's offer; champions swap)
+    CmdDeclineTrade   -> EvtTradeDeclined (either side drops the offer)
+    CmdTimeoutAdvance -> EvtGameCompleted while trading (trade window closed)
+    CmdStartNextGame  -> EvtNextGameStarted, or EvtSeriesCompleted if that win decides it
+	^ Team on the command is the winner of the game that just finished; with seats, the next game
+	starts in pre-draft again (Ready -> StartGame)
+    CmdStartTimeBank  -> EvtTimeBankStarted (lobby only, when the turn timer runs out and the team has reserve left)
+	^ the next LockPick/BanChampion/TimeoutAdvance first emits EvtTimeBankStopped{Millis used},
+	or EvtTimeBankExhausted once Millis reaches what was left
+    CmdPauseDraft     -> EvtDraftPaused{Millis left on the clock} (host only; everything but ResumeDraft is rejected until)
+    CmdResumeDraft    -> EvtDraftResumed{Millis} (lobby re-arms the clock with exactly that)
 
 */
 
@@ -82,6 +178,11 @@ type Command struct {
 	Team       Team
 	SeatID     string
 	ChampionID int
+	Name       string // display name, JoinSeat only
+	Role       string // lane, JoinSeat only
+
+	TargetSeatID string // the other seat in a trade
+	Millis       int    // timebank ms spent on this turn; filled in by the lobby
 }
 
 type EventType string
@@ -93,6 +194,30 @@ const (
 	EvtTimerStarted   EventType = "TimerStarted"
 	EvtTimerExpired   EventType = "TimerExpired"
 	EvtGameCompleted  EventType = "GameCompleted"
+
+	EvtNextGameStarted EventType = "NextGameStarted"
+	EvtSeriesCompleted EventType = "SeriesCompleted"
+
+	EvtSeatJoined   EventType = "SeatJoined"
+	EvtTeamReady    EventType = "TeamReady"
+	EvtTeamUnready  EventType = "TeamUnready"
+	EvtDraftStarted EventType = "DraftStarted"
+
+	EvtTradePhaseStarted EventType = "TradePhaseStarted"
+	EvtTradeProposed     EventType = "TradeProposed"
+	EvtTradeAccepted     EventType = "TradeAccepted"
+	EvtTradeDeclined     EventType = "TradeDeclined"
+
+	EvtTimeBankStarted   EventType = "TimeBankStarted"
+	EvtTimeBankStopped   EventType = "TimeBankStopped"
+	EvtTimeBankExhausted EventType = "TimeBankExhausted"
+
+	EvtDraftPaused  EventType = "DraftPaused"
+	EvtDraftResumed EventType = "DraftResumed"
+
+	EvtLobbyCreated    EventType = "LobbyCreated"
+	EvtChampionHovered EventType = "ChampionHovered"
+	EvtBanSkipped      EventType = "BanSkipped"
 )
 
 type Event struct {
@@ -100,15 +225,57 @@ type Event struct {
 	Team       Team
 	SeatID     string
 	ChampionID int
+	Name       string
+	Role       string
+
+	TargetSeatID string
+	Millis       int
+	Initial      *State // LobbyCreated only: rules, seed and series the lobby opened with
 }
 
 func Apply(s State, cmd Command) ([]Event, State, error) {
+	switch cmd.Type {
+	case CmdPauseDraft, CmdResumeDraft:
+		return applyPause(s, cmd)
+	}
+	if s.Paused {
+		return nil, s, ErrDraftPaused
+	}
+
+	// Commands that run between drafts rather than on a turn
+	switch cmd.Type {
+	case CmdStartNextGame:
+		return applyStartNextGame(s, cmd)
+	case CmdJoinSeat, CmdReady, CmdUnready, CmdStartGame:
+		return applyPreDraft(s, cmd)
+	case CmdProposeTrade, CmdAcceptTrade, CmdDeclineTrade:
+		return applyTrade(s, cmd)
+	case CmdTimeoutAdvance:
+		if s.Trading {
+			return applyTrade(s, cmd)
+		}
+	case CmdStartTimeBank:
+		return applyStartTimeBank(s)
+	}
+
+	// A turn taken on the timebank settles the clock first
+	if s.TimeBankActive {
+		switch cmd.Type {
+		case CmdLockPick, CmdBanChampion, CmdTimeoutAdvance:
+			return applyOnTimeBank(s, cmd)
+		}
+	}
 
-	if s.Cursor >= len(GameOrder) {
+	if s.PreDraft {
+		return nil, s, ErrDraftNotStarted
+	}
+
+	step, done := currentStep(s)
+	if done {
 		return nil, s, ErrGameAlreadyCompleted
 	}
+	lastStep := len(s.Rules.ActiveFormat().Steps) - 1
 
-	step := GameOrder[s.Cursor]
 	newState := s
 
 	switch cmd.Type {
@@ -118,6 +285,16 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 			return nil, s, ErrWrongTurn
 		}
 
+		if !isKnownChampion(s, cmd.ChampionID) {
+			return nil, s, ErrUnknownChampion
+		}
+
+		// With a roster, only the seat that owns this pick slot may lock it
+		owner, hasOwner := pickSlotOwner(s, cmd.Team)
+		if hasOwner && owner.ID != cmd.SeatID {
+			return nil, s, ErrNotYourPick
+		}
+
 		// Legality
 		if !canPick(s, cmd.Team, cmd.ChampionID) {
 			return nil, s, ErrIllegalPick
@@ -125,16 +302,20 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 		// Build Events
 
 		events := []Event{
-			{Type: EvtChampionPicked, Team: step.Team, ChampionID: cmd.ChampionID},
+			{Type: EvtChampionPicked, Team: step.Team, SeatID: owner.ID, ChampionID: cmd.ChampionID},
 			{Type: EvtTurnAdvanced},
 		}
 
 		// Mutate new state for convenience
 		newState.Picks[cmd.Team] = append(newState.Picks[cmd.Team], cmd.ChampionID)
+		newState = recordSeatPick(newState, cmd.Team, owner.ID, cmd.ChampionID)
+		clearHovers(newState, cmd.ChampionID)
 
 		//Completion
-		if s.Cursor == len(GameOrder)-1 {
-			events = append(events, Event{Type: EvtGameCompleted})
+		if s.Cursor == lastStep {
+			end := draftEndEvent(s)
+			events = append(events, end)
+			newState.Trading = end.Type == EvtTradePhaseStarted
 		}
 		return events, newState, nil
 
@@ -143,6 +324,10 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 			return nil, s, ErrWrongTurn
 		}
 
+		if !isKnownChampion(s, cmd.ChampionID) {
+			return nil, s, ErrUnknownChampion
+		}
+
 		// Legality
 		if !canBan(s, cmd.ChampionID) {
 			return nil, s, ErrIllegalBan
@@ -155,6 +340,7 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 
 		// Mutate new state for convenience
 		newState.Bans[cmd.Team] = append(newState.Bans[cmd.Team], cmd.ChampionID)
+		clearHovers(newState, cmd.ChampionID)
 		return events, newState, nil
 
 	case CmdHoverChampion:
@@ -162,11 +348,27 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 			return nil, s, ErrWrongTurn
 		}
 
+		if !isKnownChampion(s, cmd.ChampionID) {
+			return nil, s, ErrUnknownChampion
+		}
+
+		// With a roster, hovers come from a seat on the team whose turn it is
+		if len(s.Seats[cmd.Team]) > 0 && !seatOnTeam(s, cmd.Team, cmd.SeatID) {
+			return nil, s, ErrNotOnTeam
+		}
+
 		newState.Hover[cmd.SeatID] = cmd.ChampionID
-		return nil, newState, nil
+		return []Event{{Type: EvtChampionHovered, Team: cmd.Team, SeatID: cmd.SeatID, ChampionID: cmd.ChampionID}}, newState, nil
 
 	case CmdTimeoutAdvance:
-		hoveredChamp, ok := s.Hover[cmd.SeatID]
+		// The lobby's timer doesn't know who's up; use the seat owning this turn
+		seatID := cmd.SeatID
+		if seatID == "" {
+			seatID = turnSeat(s, step)
+		}
+		owner, _ := pickSlotOwner(s, step.Team)
+
+		hoveredChamp, ok := s.Hover[seatID]
 		events := []Event{}
 
 		// Conditions:
@@ -178,6 +380,7 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 			if step.Action != ActionPick {
 				// If we're banning & we haven't hovered, skip ban (advance turn)
 				events = []Event{
+					{Type: EvtBanSkipped, Team: step.Team},
 					{Type: EvtTurnAdvanced},
 				}
 				return events, s, nil
@@ -191,15 +394,19 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 				}
 
 				events = []Event{
-					{Type: EvtChampionPicked, Team: step.Team, ChampionID: c_id},
+					{Type: EvtChampionPicked, Team: step.Team, SeatID: owner.ID, ChampionID: c_id},
 					{Type: EvtTurnAdvanced},
 				}
 
-				if s.Cursor == len(GameOrder)-1 {
-					events = append(events, Event{Type: EvtGameCompleted})
+				if s.Cursor == lastStep {
+					end := draftEndEvent(s)
+					events = append(events, end)
+					newState.Trading = end.Type == EvtTradePhaseStarted
 				}
 
 				newState.Picks[step.Team] = append(newState.Picks[step.Team], c_id)
+				newState = recordSeatPick(newState, step.Team, owner.ID, c_id)
+				clearHovers(newState, c_id)
 
 				return events, newState, nil
 			}
@@ -219,7 +426,7 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 				{Type: EvtTurnAdvanced},
 			}
 			newState.Bans[step.Team] = append(newState.Bans[step.Team], hoveredChamp)
-			delete(newState.Hover, cmd.SeatID)
+			clearHovers(newState, hoveredChamp)
 
 		} else {
 			// Picking & hovered exists
@@ -230,14 +437,17 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 			}
 
 			events = []Event{
-				{Type: EvtChampionPicked, Team: step.Team, ChampionID: hoveredChamp},
+				{Type: EvtChampionPicked, Team: step.Team, SeatID: owner.ID, ChampionID: hoveredChamp},
 				{Type: EvtTurnAdvanced},
 			}
-			if s.Cursor == len(GameOrder)-1 {
-				events = append(events, Event{Type: EvtGameCompleted})
+			if s.Cursor == lastStep {
+				end := draftEndEvent(s)
+				events = append(events, end)
+				newState.Trading = end.Type == EvtTradePhaseStarted
 			}
 			newState.Picks[step.Team] = append(newState.Picks[step.Team], hoveredChamp)
-			delete(newState.Hover, cmd.SeatID)
+			newState = recordSeatPick(newState, step.Team, owner.ID, hoveredChamp)
+			clearHovers(newState, hoveredChamp)
 		}
 
 		return events, newState, nil
@@ -248,25 +458,73 @@ func Apply(s State, cmd Command) ([]Event, State, error) {
 }
 
 func Reduce(events []Event) State {
-	s := NewEmptyState()
-	s.Cursor = 0
+	return ReduceFrom(NewEmptyState(), events)
+}
+
+// ReduceFrom replays events on top of the state a lobby started with, so
+// per-lobby settings like Rules and Seed survive the rebuild. A log that
+// starts with EvtLobbyCreated carries its own starting state.
+func ReduceFrom(initial State, events []Event) State {
+	s := initial.Clone()
 	for _, event := range events {
-		switch event.Type {
-		case EvtChampionPicked:
-			s.Picks[event.Team] = append(s.Picks[event.Team], event.ChampionID)
-		case EvtChampionBanned:
-			s.Bans[event.Team] = append(s.Bans[event.Team], event.ChampionID)
-		case EvtTurnAdvanced:
-			s.Cursor++
-		case EvtGameCompleted:
-			s.Phase = PhaseDone
+		s = Fold(s, event)
+	}
+	return s
+}
+
+// Fold applies a single event to s. It's the only place lobby state changes:
+// the lobby folds every event Apply emits, and replaying a log is folding it
+// from the start. s's maps may be written to; pass a clone to keep the original.
+func Fold(s State, event Event) State {
+	switch event.Type {
+	case EvtLobbyCreated:
+		if event.Initial != nil {
+			s = event.Initial.Clone()
 		}
+	case EvtChampionPicked:
+		s.Picks[event.Team] = append(s.Picks[event.Team], event.ChampionID)
+		s = recordSeatPick(s, event.Team, event.SeatID, event.ChampionID)
+		clearHovers(s, event.ChampionID)
+	case EvtChampionBanned:
+		s.Bans[event.Team] = append(s.Bans[event.Team], event.ChampionID)
+		clearHovers(s, event.ChampionID)
+	case EvtChampionHovered:
+		if s.Hover == nil {
+			s.Hover = map[string]int{}
+		}
+		s.Hover[event.SeatID] = event.ChampionID
+	case EvtTurnAdvanced:
+		s.Cursor++
+	case EvtGameCompleted:
+		s.Phase = PhaseDone
+		s = reduceTrade(s, event)
+	case EvtNextGameStarted:
+		s = startNextGame(s, event.Team)
+	case EvtSeriesCompleted:
+		s.Series = recordWin(s.Series, event.Team)
+	case EvtSeatJoined, EvtTeamReady, EvtTeamUnready, EvtDraftStarted:
+		s = reducePreDraft(s, event)
+	case EvtTradePhaseStarted, EvtTradeProposed, EvtTradeAccepted, EvtTradeDeclined:
+		s = reduceTrade(s, event)
+	case EvtTimeBankStarted, EvtTimeBankStopped, EvtTimeBankExhausted:
+		s = reduceTimeBank(s, event)
+	case EvtDraftPaused, EvtDraftResumed:
+		s = reducePause(s, event)
 	}
 
-	s.Phase = DerivePhase(s.Cursor)
+	s.Phase = DerivePhase(s)
 	return s
 }
 
+// clearHovers drops every hover pointing at a champion that was just taken.
+func clearHovers(s State, id int) {
+	for seat, champ := range s.Hover {
+		if champ == id {
+			delete(s.Hover, seat)
+		}
+	}
+}
+
 func hasPick(s State, id int) bool {
 	exists := slices.Contains(s.Picks[TeamBlue], id) || slices.Contains(s.Picks[TeamRed], id)
 	return exists
@@ -281,20 +539,33 @@ func canPick(s State, team Team, id int) bool {
 		return false
 	}
 
-	if s.Rules.Fearless && s.Fearless[id] {
+	if fearlessLocked(s, team, id) {
 		return false
 	}
 
 	return true
 }
 
+// fearlessLocked reports whether team may not pick id because of an earlier game.
+func fearlessLocked(s State, team Team, id int) bool {
+	switch s.Rules.Fearless {
+	case FearlessHard:
+		return slices.Contains(s.Fearless[TeamBlue], id) || slices.Contains(s.Fearless[TeamRed], id)
+	case FearlessTeam:
+		return slices.Contains(s.Fearless[team], id)
+	default:
+		return false
+	}
+}
+
 func hasBan(s State, id int) bool {
 	exists := slices.Contains(s.Bans[TeamBlue], id) || slices.Contains(s.Bans[TeamRed], id)
 	return exists
 }
 
 func canBan(s State, id int) bool {
-	if s.Fearless[id] {
+	// No point banning what neither team is allowed to pick anyway
+	if fearlessLocked(s, TeamBlue, id) && fearlessLocked(s, TeamRed, id) {
 		return false
 	}
 	if hasBan(s, id) {
@@ -308,8 +579,15 @@ func canBan(s State, id int) bool {
 }
 
 func currentStep(s State) (TurnStep, bool) {
-	if s.Cursor >= len(GameOrder) {
+	steps := s.Rules.ActiveFormat().Steps
+	if s.Cursor >= len(steps) {
 		return TurnStep{}, true
 	}
-	return GameOrder[s.Cursor], false
+	return steps[s.Cursor], false
+}
+
+// CurrentStep reports whose turn it is and what they're doing; done is true
+// once the cursor has run past the end of the format.
+func CurrentStep(s State) (step TurnStep, done bool) {
+	return currentStep(s)
 }
@@ -2,7 +2,10 @@ package engine
 
 import (
 	"errors"
+	"fmt"
+	"math/rand/v2"
 	"reflect"
+	"slices"
 	"testing"
 )
 
@@ -80,8 +83,8 @@ func TestCanPickChampion(t *testing.T) {
 				Cursor:   7, // Red Team Pick 1
 				Picks:    map[Team][]int{TeamBlue: {266}, TeamRed: {}},
 				Bans:     map[Team][]int{TeamBlue: {1, 2, 3}, TeamRed: {4, 5, 6}},
-				Rules:    Rules{Fearless: true},
-				Fearless: map[int]bool{222: true, 251: true, 45: true},
+				Rules:    Rules{Fearless: FearlessHard},
+				Fearless: map[Team][]int{TeamBlue: {222, 251}, TeamRed: {45}},
 				Hover:    map[string]int{},
 			},
 			cmd:          Command{Type: CmdLockPick, Team: TeamRed, ChampionID: 222},
@@ -314,7 +317,7 @@ func TestDerivePhase_FromCursorRanges(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			phase := DerivePhase(tc.cursor)
+			phase := DerivePhase(State{Cursor: tc.cursor})
 			if phase != tc.wantPhase {
 				t.Fatalf("Wanted %v, got %v", tc.wantPhase, phase)
 			}
@@ -323,7 +326,7 @@ func TestDerivePhase_FromCursorRanges(t *testing.T) {
 }
 
 func TestDerivePhase_ZeroIsBan1(t *testing.T) {
-	phase := DerivePhase(0)
+	phase := DerivePhase(State{})
 
 	if phase != PhaseBan1 {
 		t.Fatalf("Expected PhaseBan1, got %v", phase)
@@ -354,14 +357,18 @@ func TestHover_RejectsWhenNotPickTurn(t *testing.T) {
 	}
 }
 
-func TestHover_IsTransient_NoEventsEmitted(t *testing.T) {
+func TestHover_EmitsHoverEventForTheLog(t *testing.T) {
 	s := NewEmptyState()
 	s.Cursor = 6
 	cmd := Command{Type: CmdHoverChampion, Team: TeamBlue, SeatID: "Jack", ChampionID: 8}
 
-	events, _, _ := Apply(s, cmd)
-	if events != nil {
-		t.Fatalf("Expected empty events, got %v", events)
+	events, _, _ := Apply(s.Clone(), cmd)
+	want := []Event{{Type: EvtChampionHovered, Team: TeamBlue, SeatID: "Jack", ChampionID: 8}}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("Expected %v, got %v", want, events)
+	}
+	if got := ReduceFrom(s, events); got.Hover["Jack"] != 8 {
+		t.Fatalf("Expected the hover to replay, got %v", got.Hover)
 	}
 }
 
@@ -441,3 +448,844 @@ func TestTimeoutAdvance_SkipsBanWhenNoHover(t *testing.T) {
 		t.Fatalf("expected EvtTurnAdvanced: %v", events)
 	}
 }
+
+func TestDerivePhase_UsesRulesFormat(t *testing.T) {
+	cases := []struct {
+		name      string
+		format    DraftFormat
+		cursor    int
+		wantPhase Phase
+	}{
+		{name: "Clash last ban", format: FormatClash, cursor: 9, wantPhase: PhaseBan1},
+		{name: "Clash first pick", format: FormatClash, cursor: 10, wantPhase: PhasePick1},
+		{name: "Legacy has no ban2", format: FormatLegacy3Ban, cursor: 12, wantPhase: PhasePick1},
+		{name: "Legacy done", format: FormatLegacy3Ban, cursor: 16, wantPhase: PhaseDone},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := State{Cursor: tc.cursor, Rules: Rules{Format: tc.format}}
+			if phase := DerivePhase(s); phase != tc.wantPhase {
+				t.Fatalf("Wanted %v, got %v", tc.wantPhase, phase)
+			}
+		})
+	}
+}
+
+func TestApply_LegacyFormatCompletesOnItsLastStep(t *testing.T) {
+	s := NewEmptyState()
+	s.Rules.Format = FormatLegacy3Ban
+	s.Cursor = len(FormatLegacy3Ban.Steps) - 1
+	cmd := Command{Type: CmdLockPick, Team: FormatLegacy3Ban.Steps[s.Cursor].Team, ChampionID: 99}
+
+	events, _, err := Apply(s, cmd)
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if !ContainsEvent(events, EvtGameCompleted) {
+		t.Fatalf("expected EvtGameCompleted")
+	}
+
+	s.Cursor++
+	if _, _, err := Apply(s, cmd); !errors.Is(err, ErrGameAlreadyCompleted) {
+		t.Fatalf("want ErrGameAlreadyCompleted, got %v", err)
+	}
+}
+
+func TestLookupFormat(t *testing.T) {
+	if f, ok := LookupFormat(""); !ok || f.ID != FormatTournament.ID {
+		t.Fatalf("empty id should default to tournament, got %q %v", f.ID, ok)
+	}
+	if f, ok := LookupFormat("clash"); !ok || f.ID != "clash" {
+		t.Fatalf("expected clash format, got %q %v", f.ID, ok)
+	}
+	if _, ok := LookupFormat("nope"); ok {
+		t.Fatalf("expected unknown format to miss")
+	}
+}
+
+func TestRegisterFormat_RejectsDuplicateAndInvalid(t *testing.T) {
+	if err := RegisterFormat(FormatClash); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("want ErrInvalidFormat for duplicate id, got %v", err)
+	}
+
+	bad := DraftFormat{ID: "lopsided", Steps: []TurnStep{{Team: TeamBlue, Action: ActionPick}}}
+	bad.Phases = []PhaseSpan{{Phase: PhasePick1, Start: 0, End: 1}}
+	if err := RegisterFormat(bad); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("want ErrInvalidFormat for uneven picks, got %v", err)
+	}
+	if _, ok := LookupFormat("lopsided"); ok {
+		t.Fatalf("invalid format should not be registered")
+	}
+}
+
+func TestBuiltinFormatsAreValid(t *testing.T) {
+	for _, f := range []DraftFormat{FormatTournament, FormatLegacy3Ban, FormatClash} {
+		if err := f.Validate(); err != nil {
+			t.Fatalf("%s: %v", f.ID, err)
+		}
+	}
+}
+
+type fakeRoster map[int]bool
+
+func (r fakeRoster) Has(id int) bool { return r[id] }
+func (r fakeRoster) IDs() []int {
+	ids := []int{}
+	for id := range r {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestApply_RejectsUnknownChampion(t *testing.T) {
+	champs := fakeRoster{266: true, 103: true}
+
+	cases := []struct {
+		name   string
+		cursor int
+		cmd    Command
+	}{
+		{name: "pick outside roster", cursor: 6, cmd: Command{Type: CmdLockPick, Team: TeamBlue, ChampionID: 999999}},
+		{name: "pick zero", cursor: 6, cmd: Command{Type: CmdLockPick, Team: TeamBlue, ChampionID: 0}},
+		{name: "ban outside roster", cursor: 0, cmd: Command{Type: CmdBanChampion, Team: TeamBlue, ChampionID: 999999}},
+		{name: "hover outside roster", cursor: 6, cmd: Command{Type: CmdHoverChampion, Team: TeamBlue, SeatID: "Jack", ChampionID: 5}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewEmptyState()
+			s.Rules.Champions = champs
+			s.Cursor = tc.cursor
+			if _, _, err := Apply(s, tc.cmd); !errors.Is(err, ErrUnknownChampion) {
+				t.Fatalf("want ErrUnknownChampion, got %v", err)
+			}
+		})
+	}
+
+	s := NewEmptyState()
+	s.Rules.Champions = champs
+	s.Cursor = 6
+	if _, _, err := Apply(s, Command{Type: CmdLockPick, Team: TeamBlue, ChampionID: 266}); err != nil {
+		t.Fatalf("unexpected err for known champion: %v", err)
+	}
+
+	// No roster on the rules: checked against the built-in list instead
+	s = NewEmptyState()
+	s.Cursor = 6
+	if _, _, err := Apply(s, Command{Type: CmdLockPick, Team: TeamBlue, ChampionID: 999999}); !errors.Is(err, ErrUnknownChampion) {
+		t.Fatalf("want ErrUnknownChampion without a roster, got %v", err)
+	}
+	if _, _, err := Apply(s, Command{Type: CmdLockPick, Team: TeamBlue, ChampionID: 266}); err != nil {
+		t.Fatalf("unexpected err for a built-in champion: %v", err)
+	}
+}
+
+func TestChooseRandomLegal_SkipsBannedPickedAndFearless(t *testing.T) {
+	s := NewEmptyState()
+	s.Rules.Champions = fakeRoster{1: true, 2: true, 3: true, 4: true}
+	s.Cursor = 6
+	s.Bans = map[Team][]int{TeamBlue: {1}, TeamRed: {}}
+	s.Picks = map[Team][]int{TeamBlue: {}, TeamRed: {2}}
+	s.Rules.Fearless = FearlessHard
+	s.Fearless = map[Team][]int{TeamBlue: {}, TeamRed: {3}}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		s.Seed = seed
+		id, ok := chooseRandomLegal(s, TeamBlue)
+		if !ok || id != 4 {
+			t.Fatalf("seed %d: want 4, got %d (%v)", seed, id, ok)
+		}
+	}
+
+	s.Picks[TeamBlue] = []int{4}
+	if _, ok := chooseRandomLegal(s, TeamRed); ok {
+		t.Fatalf("expected no legal champion left")
+	}
+}
+
+func TestChooseRandomLegal_BuiltinRosterWithoutCatalog(t *testing.T) {
+	s := NewEmptyState()
+	s.Cursor = 6
+	s.Seed = 42
+	s.Bans = map[Team][]int{TeamBlue: builtinChampionIDs[:len(builtinChampionIDs)-1], TeamRed: {}}
+
+	id, ok := chooseRandomLegal(s, TeamBlue)
+	if want := builtinChampionIDs[len(builtinChampionIDs)-1]; !ok || id != want {
+		t.Fatalf("want the one unbanned built-in champion %d, got %d (%v)", want, id, ok)
+	}
+}
+
+func TestTimeoutAdvance_RandomPickReplaysFromLog(t *testing.T) {
+	ids := fakeRoster{}
+	for id := 1; id <= 150; id++ {
+		ids[id] = true
+	}
+
+	initial := NewEmptyState()
+	initial.Rules.Champions = ids
+	initial.Seed = 987654321
+	initial.Cursor = 6
+
+	// Drive two auto-picks the way the lobby does (it owns the cursor bump).
+	live := initial.Clone()
+	var log []Event
+	for range 2 {
+		events, ns, err := Apply(live, Command{Type: CmdTimeoutAdvance})
+		if err != nil {
+			t.Fatalf("unexpected: %v", err)
+		}
+		log = append(log, events...)
+		live = ns
+		live.Cursor++
+	}
+
+	replayed := ReduceFrom(initial, log)
+	if !reflect.DeepEqual(replayed.Picks, live.Picks) || replayed.Cursor != live.Cursor {
+		t.Fatalf("replay mismatch.\n got: %v @%d\nwant: %v @%d", replayed.Picks, replayed.Cursor, live.Picks, live.Cursor)
+	}
+
+	// The next auto-pick must be the same whether we roll from the live or replayed state.
+	step, _ := currentStep(live)
+	liveNext, _ := chooseRandomLegal(live, step.Team)
+	replayNext, _ := chooseRandomLegal(replayed, step.Team)
+	if liveNext != replayNext {
+		t.Fatalf("next auto-pick diverged: live %d, replay %d", liveNext, replayNext)
+	}
+
+	if initial.Picks[TeamBlue] == nil || len(initial.Picks[TeamBlue]) != 0 {
+		t.Fatalf("ReduceFrom must not mutate the initial state: %v", initial.Picks)
+	}
+}
+
+func finishedGame(bestOf int, fearless FearlessMode) State {
+	s := NewEmptyState()
+	s.Rules.Fearless = fearless
+	s.Series = NewSeries(bestOf)
+	s.Cursor = len(GameOrder)
+	s.Picks = map[Team][]int{TeamBlue: {1, 2, 3, 4, 5}, TeamRed: {6, 7, 8, 9, 10}}
+	s.Bans = map[Team][]int{TeamBlue: {11, 12}, TeamRed: {13, 14}}
+	s.Phase = DerivePhase(s)
+	return s
+}
+
+func TestStartNextGame_CarriesPicksIntoFearless(t *testing.T) {
+	s := finishedGame(3, FearlessHard)
+
+	events, ns, err := Apply(s, Command{Type: CmdStartNextGame, Team: TeamRed})
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if !ContainsEvent(events, EvtNextGameStarted) {
+		t.Fatalf("expected EvtNextGameStarted, got %v", events)
+	}
+	if ns.Cursor != 0 || ns.Phase != PhaseBan1 || len(ns.Picks[TeamBlue]) != 0 || len(ns.Bans[TeamRed]) != 0 {
+		t.Fatalf("expected a fresh draft, got cursor=%d phase=%v picks=%v bans=%v", ns.Cursor, ns.Phase, ns.Picks, ns.Bans)
+	}
+	if ns.Series.GameIndex != 1 || ns.Series.Score[TeamRed] != 1 {
+		t.Fatalf("unexpected series %+v", ns.Series)
+	}
+	want := map[Team][]int{TeamBlue: {1, 2, 3, 4, 5}, TeamRed: {6, 7, 8, 9, 10}}
+	if !reflect.DeepEqual(ns.Fearless, want) {
+		t.Fatalf("expected picks (not bans) carried per team, got %v", ns.Fearless)
+	}
+	if s.Series.Score[TeamRed] != 0 {
+		t.Fatalf("Apply must not mutate the previous series score")
+	}
+
+	if !reflect.DeepEqual(ReduceFrom(s, events), ns) {
+		t.Fatalf("replay mismatch.\n got: %#v\nwant: %#v", ReduceFrom(s, events), ns)
+	}
+}
+
+func TestStartNextGame_NoFearlessWhenRuleOff(t *testing.T) {
+	_, ns, err := Apply(finishedGame(3, FearlessOff), Command{Type: CmdStartNextGame, Team: TeamBlue})
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if len(ns.Fearless[TeamBlue]) != 0 || len(ns.Fearless[TeamRed]) != 0 {
+		t.Fatalf("expected no fearless locks, got %v", ns.Fearless)
+	}
+}
+
+func TestStartNextGame_Rejections(t *testing.T) {
+	inProgress := NewEmptyState()
+	inProgress.Cursor = 10
+	if _, _, err := Apply(inProgress, Command{Type: CmdStartNextGame, Team: TeamBlue}); !errors.Is(err, ErrGameInProgress) {
+		t.Fatalf("want ErrGameInProgress, got %v", err)
+	}
+
+	if _, _, err := Apply(finishedGame(3, FearlessHard), Command{Type: CmdStartNextGame}); !errors.Is(err, ErrInvalidTeam) {
+		t.Fatalf("want ErrInvalidTeam, got %v", err)
+	}
+
+	decided := finishedGame(3, FearlessHard)
+	decided.Series.Score = map[Team]int{TeamBlue: 2, TeamRed: 0}
+	if _, _, err := Apply(decided, Command{Type: CmdStartNextGame, Team: TeamBlue}); !errors.Is(err, ErrSeriesOver) {
+		t.Fatalf("want ErrSeriesOver, got %v", err)
+	}
+}
+
+func TestStartNextGame_ClinchingWinCompletesSeries(t *testing.T) {
+	s := finishedGame(3, FearlessHard)
+	s.Series.Score = map[Team]int{TeamBlue: 1, TeamRed: 1}
+	s.Series.GameIndex = 2
+
+	events, ns, err := Apply(s, Command{Type: CmdStartNextGame, Team: TeamBlue})
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if !ContainsEvent(events, EvtSeriesCompleted) || ContainsEvent(events, EvtNextGameStarted) {
+		t.Fatalf("expected only EvtSeriesCompleted, got %v", events)
+	}
+	if winner, over := ns.Series.Winner(); !over || winner != TeamBlue {
+		t.Fatalf("expected blue to win the series, got %v %v", winner, over)
+	}
+	if ns.Cursor != len(GameOrder) {
+		t.Fatalf("board should stay on the finished game")
+	}
+}
+
+func TestFearlessModes_PickAndBanLegality(t *testing.T) {
+	// 10 was played by blue last game, 20 by both teams, 30 by nobody.
+	played := map[Team][]int{TeamBlue: {10, 20}, TeamRed: {20}}
+
+	cases := []struct {
+		mode        FearlessMode
+		id          int
+		blueCanPick bool
+		redCanPick  bool
+		canBanID    bool
+	}{
+		{mode: FearlessOff, id: 10, blueCanPick: true, redCanPick: true, canBanID: true},
+		{mode: FearlessOff, id: 20, blueCanPick: true, redCanPick: true, canBanID: true},
+		{mode: "", id: 20, blueCanPick: true, redCanPick: true, canBanID: true},
+		{mode: FearlessHard, id: 10, blueCanPick: false, redCanPick: false, canBanID: false},
+		{mode: FearlessHard, id: 20, blueCanPick: false, redCanPick: false, canBanID: false},
+		{mode: FearlessHard, id: 30, blueCanPick: true, redCanPick: true, canBanID: true},
+		{mode: FearlessTeam, id: 10, blueCanPick: false, redCanPick: true, canBanID: true},
+		{mode: FearlessTeam, id: 20, blueCanPick: false, redCanPick: false, canBanID: false},
+		{mode: FearlessTeam, id: 30, blueCanPick: true, redCanPick: true, canBanID: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s/%d", tc.mode, tc.id), func(t *testing.T) {
+			s := NewEmptyState()
+			s.Rules.Fearless = tc.mode
+			s.Fearless = played
+
+			if got := canPick(s, TeamBlue, tc.id); got != tc.blueCanPick {
+				t.Fatalf("blue canPick: got %v, want %v", got, tc.blueCanPick)
+			}
+			if got := canPick(s, TeamRed, tc.id); got != tc.redCanPick {
+				t.Fatalf("red canPick: got %v, want %v", got, tc.redCanPick)
+			}
+			if got := canBan(s, tc.id); got != tc.canBanID {
+				t.Fatalf("canBan: got %v, want %v", got, tc.canBanID)
+			}
+		})
+	}
+}
+
+func TestStartNextGame_TeamFearlessTracksPerTeam(t *testing.T) {
+	s := finishedGame(5, FearlessTeam)
+	s.Fearless = map[Team][]int{TeamBlue: {50}, TeamRed: {}}
+
+	_, ns, err := Apply(s, Command{Type: CmdStartNextGame, Team: TeamBlue})
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if !canPick(ns, TeamRed, 1) || canPick(ns, TeamBlue, 1) {
+		t.Fatalf("blue's game-1 pick should only lock blue")
+	}
+	if !slices.Contains(ns.Fearless[TeamBlue], 50) {
+		t.Fatalf("locks from earlier games must carry forward, got %v", ns.Fearless)
+	}
+}
+
+func TestStartNextGame_SeatedDraftGoesBackToPreDraft(t *testing.T) {
+	s := finishedGame(3, FearlessHard)
+	s.Seats = map[Team][]Seat{TeamBlue: {{ID: "b1"}}, TeamRed: {{ID: "r1"}}}
+	s.Ready = map[Team]bool{TeamBlue: true, TeamRed: true}
+
+	events, ns, err := Apply(s, Command{Type: CmdStartNextGame, Team: TeamBlue})
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if !ns.PreDraft || ns.Phase != PhaseLobby || ns.Ready[TeamBlue] || ns.Ready[TeamRed] {
+		t.Fatalf("want game 2 back in pre-draft with nobody ready, got phase=%v ready=%v", ns.Phase, ns.Ready)
+	}
+	if got := Reduce(append([]Event{{Type: EvtLobbyCreated, Initial: &s}}, events...)); !got.PreDraft {
+		t.Fatalf("replay disagrees with Apply on pre-draft")
+	}
+
+	// No picks until both captains ready up and the game is started
+	if _, _, err := Apply(ns.Clone(), Command{Type: CmdBanChampion, Team: TeamBlue, ChampionID: 20}); !errors.Is(err, ErrDraftNotStarted) {
+		t.Fatalf("want ErrDraftNotStarted before StartGame, got %v", err)
+	}
+	if _, _, err := Apply(ns.Clone(), Command{Type: CmdStartGame}); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("want ErrNotReady, got %v", err)
+	}
+	for _, cmd := range []Command{
+		{Type: CmdReady, Team: TeamBlue, SeatID: "b1"},
+		{Type: CmdReady, Team: TeamRed, SeatID: "r1"},
+		{Type: CmdStartGame},
+	} {
+		if _, ns, err = Apply(ns, cmd); err != nil {
+			t.Fatalf("%s: %v", cmd.Type, err)
+		}
+	}
+	if ns.PreDraft || ns.Phase != PhaseBan1 || ns.Series.GameIndex != 1 {
+		t.Fatalf("want game 2's ban1 after StartGame, got phase=%v game=%d", ns.Phase, ns.Series.GameIndex)
+	}
+}
+
+func TestPreDraft_SeatsReadyAndStart(t *testing.T) {
+	s := NewPreDraftState()
+	if s.Phase != PhaseLobby {
+		t.Fatalf("want PhaseLobby, got %v", s.Phase)
+	}
+	initial := s.Clone()
+
+	var log []Event
+	apply := func(cmd Command) error {
+		events, ns, err := Apply(s, cmd)
+		if err == nil {
+			s = ns
+			log = append(log, events...)
+		}
+		return err
+	}
+
+	if err := apply(Command{Type: CmdLockPick, Team: TeamBlue, ChampionID: 1}); !errors.Is(err, ErrDraftNotStarted) {
+		t.Fatalf("want ErrDraftNotStarted, got %v", err)
+	}
+	if err := apply(Command{Type: CmdJoinSeat, Team: TeamBlue, SeatID: "b1", Name: "Jack"}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if err := apply(Command{Type: CmdJoinSeat, Team: TeamBlue, SeatID: "b2"}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if err := apply(Command{Type: CmdJoinSeat, Team: TeamRed, SeatID: "b1"}); !errors.Is(err, ErrSeatTaken) {
+		t.Fatalf("want ErrSeatTaken, got %v", err)
+	}
+	if err := apply(Command{Type: CmdJoinSeat, Team: TeamRed, SeatID: "r1"}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+
+	if err := apply(Command{Type: CmdReady, Team: TeamBlue, SeatID: "b2"}); !errors.Is(err, ErrNotCaptain) {
+		t.Fatalf("want ErrNotCaptain, got %v", err)
+	}
+	if err := apply(Command{Type: CmdReady, Team: TeamBlue, SeatID: "b1"}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if err := apply(Command{Type: CmdStartGame}); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("want ErrNotReady, got %v", err)
+	}
+	if err := apply(Command{Type: CmdReady, Team: TeamRed, SeatID: "r1"}); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if s.Phase != PhaseReady {
+		t.Fatalf("want PhaseReady, got %v", s.Phase)
+	}
+
+	events, ns, err := Apply(s, Command{Type: CmdStartGame})
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if !ContainsEvent(events, EvtTimerStarted) || ns.PreDraft || ns.Phase != PhaseBan1 {
+		t.Fatalf("expected draft to start in ban1, got events=%v phase=%v", events, ns.Phase)
+	}
+	log = append(log, events...)
+
+	if _, _, err := Apply(ns, Command{Type: CmdJoinSeat, Team: TeamRed, SeatID: "late"}); !errors.Is(err, ErrDraftStarted) {
+		t.Fatalf("want ErrDraftStarted, got %v", err)
+	}
+	if !reflect.DeepEqual(ReduceFrom(initial, log), ns) {
+		t.Fatalf("replay mismatch.\n got: %#v\nwant: %#v", ReduceFrom(initial, log), ns)
+	}
+	if len(initial.Seats[TeamBlue]) != 0 {
+		t.Fatalf("Apply must not mutate earlier states' seats")
+	}
+}
+
+func TestPreDraft_TeamFull(t *testing.T) {
+	s := NewPreDraftState()
+	for i := range MaxSeatsPerTeam {
+		_, ns, err := Apply(s, Command{Type: CmdJoinSeat, Team: TeamRed, SeatID: fmt.Sprintf("r%d", i)})
+		if err != nil {
+			t.Fatalf("unexpected: %v", err)
+		}
+		s = ns
+	}
+	if _, _, err := Apply(s, Command{Type: CmdJoinSeat, Team: TeamRed, SeatID: "extra"}); !errors.Is(err, ErrTeamFull) {
+		t.Fatalf("want ErrTeamFull, got %v", err)
+	}
+}
+
+// startedWithRoster seats blue as b1..b5 (one pick each) and red as a lone captain.
+func startedWithRoster(t *testing.T) State {
+	t.Helper()
+	s := NewPreDraftState()
+	cmds := []Command{}
+	for i, role := range []string{"top", "jungle", "mid", "bot", "support"} {
+		cmds = append(cmds, Command{Type: CmdJoinSeat, Team: TeamBlue, SeatID: fmt.Sprintf("b%d", i+1), Role: role})
+	}
+	cmds = append(cmds,
+		Command{Type: CmdJoinSeat, Team: TeamRed, SeatID: "r1"},
+		Command{Type: CmdReady, Team: TeamBlue, SeatID: "b1"},
+		Command{Type: CmdReady, Team: TeamRed, SeatID: "r1"},
+		Command{Type: CmdStartGame},
+	)
+	for _, cmd := range cmds {
+		_, ns, err := Apply(s, cmd)
+		if err != nil {
+			t.Fatalf("%s: %v", cmd.Type, err)
+		}
+		s = ns
+	}
+	return s
+}
+
+func TestSeats_PickSlotsDealtOnStart(t *testing.T) {
+	s := startedWithRoster(t)
+
+	for i, seat := range s.Seats[TeamBlue] {
+		if !reflect.DeepEqual(seat.PickSlots, []int{i}) {
+			t.Fatalf("%s: want slot [%d], got %v", seat.ID, i, seat.PickSlots)
+		}
+	}
+	if s.Seats[TeamBlue][2].Role != "mid" {
+		t.Fatalf("expected role carried onto seat, got %+v", s.Seats[TeamBlue][2])
+	}
+	if got := s.Seats[TeamRed][0].PickSlots; !reflect.DeepEqual(got, []int{0, 1, 2, 3, 4}) {
+		t.Fatalf("lone captain should own every red slot, got %v", got)
+	}
+}
+
+func TestLockPick_OnlyFromSlotOwner(t *testing.T) {
+	s := startedWithRoster(t)
+	s.Cursor = 6 // blue's first pick → slot 0 → b1
+	s.Phase = DerivePhase(s)
+
+	if _, _, err := Apply(s, Command{Type: CmdLockPick, Team: TeamBlue, SeatID: "b2", ChampionID: 266}); !errors.Is(err, ErrNotYourPick) {
+		t.Fatalf("want ErrNotYourPick, got %v", err)
+	}
+
+	events, ns, err := Apply(s, Command{Type: CmdLockPick, Team: TeamBlue, SeatID: "b1", ChampionID: 266})
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if events[0].SeatID != "b1" {
+		t.Fatalf("expected pick event credited to b1, got %+v", events[0])
+	}
+	if got := ns.Seats[TeamBlue][0].Champions; !reflect.DeepEqual(got, []int{266}) {
+		t.Fatalf("expected b1 to hold 266, got %v", got)
+	}
+	if len(s.Seats[TeamBlue][0].Champions) != 0 {
+		t.Fatalf("Apply must not mutate the previous state's seats")
+	}
+}
+
+func TestTimeoutAdvance_UsesSlotOwnersHover(t *testing.T) {
+	s := startedWithRoster(t)
+	s.Cursor = 9 // blue's second pick → slot 1 → b2
+	s.Picks = map[Team][]int{TeamBlue: {1}, TeamRed: {2, 3}}
+	s.Hover = map[string]int{"b2": 77, "b3": 88}
+
+	events, ns, err := Apply(s, Command{Type: CmdTimeoutAdvance})
+	if err != nil {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if events[0].ChampionID != 77 || events[0].SeatID != "b2" {
+		t.Fatalf("expected b2's hover to lock, got %+v", events[0])
+	}
+	if !reflect.DeepEqual(ns.Seats[TeamBlue][1].Champions, []int{77}) {
+		t.Fatalf("expected b2 to hold 77, got %v", ns.Seats[TeamBlue][1].Champions)
+	}
+}
+
+func TestHover_RejectsSeatFromOtherTeam(t *testing.T) {
+	s := startedWithRoster(t)
+	s.Cursor = 6
+
+	if _, _, err := Apply(s, Command{Type: CmdHoverChampion, Team: TeamBlue, SeatID: "r1", ChampionID: 8}); !errors.Is(err, ErrNotOnTeam) {
+		t.Fatalf("want ErrNotOnTeam, got %v", err)
+	}
+}
+
+// draftedWithTrade is startedWithRoster with blue's five picks made (one per
+// seat) and red locking the final pick into the trade window.
+func draftedWithTrade(t *testing.T) State {
+	t.Helper()
+	s := startedWithRoster(t)
+	s.Rules.TradeTimerSec = 30
+	s.Cursor = len(GameOrder) - 1
+	s.Picks = map[Team][]int{TeamBlue: {}, TeamRed: {6, 7, 8, 9}}
+	for i := range 5 {
+		s.Picks[TeamBlue] = append(s.Picks[TeamBlue], i+1)
+		s = recordSeatPick(s, TeamBlue, fmt.Sprintf("b%d", i+1), i+1)
+	}
+
+	events, ns, err := Apply(s, Command{Type: CmdLockPick, Team: TeamRed, SeatID: "r1", ChampionID: 10})
+	if err != nil {
+		t.Fatalf("last pick: %v", err)
+	}
+	if !ContainsEvent(events, EvtTradePhaseStarted) || ContainsEvent(events, EvtGameCompleted) {
+		t.Fatalf("expected trade phase instead of completion, got %v", events)
+	}
+	ns.Cursor++
+	ns.Phase = DerivePhase(ns)
+	return ns
+}
+
+func TestTrade_SwapsChampionsBetweenTeammates(t *testing.T) {
+	s := draftedWithTrade(t)
+	if s.Phase != PhaseTrade {
+		t.Fatalf("want PhaseTrade, got %v", s.Phase)
+	}
+	initial := s.Clone()
+
+	var log []Event
+	apply := func(cmd Command) error {
+		events, ns, err := Apply(s, cmd)
+		if err == nil {
+			s = ns
+			log = append(log, events...)
+		}
+		return err
+	}
+
+	if err := apply(Command{Type: CmdProposeTrade, Team: TeamBlue, SeatID: "b1", TargetSeatID: "r1"}); !errors.Is(err, ErrNotOnTeam) {
+		t.Fatalf("want ErrNotOnTeam for cross-team trade, got %v", err)
+	}
+	if err := apply(Command{Type: CmdProposeTrade, Team: TeamBlue, SeatID: "b1", TargetSeatID: "b3"}); err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+	if err := apply(Command{Type: CmdAcceptTrade, Team: TeamBlue, SeatID: "b1", TargetSeatID: "b3"}); !errors.Is(err, ErrIllegalTrade) {
+		t.Fatalf("proposer must not accept their own offer, got %v", err)
+	}
+	if err := apply(Command{Type: CmdProposeTrade, Team: TeamBlue, SeatID: "b2", TargetSeatID: "b4"}); err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+	if err := apply(Command{Type: CmdDeclineTrade, Team: TeamBlue, SeatID: "b4", TargetSeatID: "b2"}); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	if err := apply(Command{Type: CmdAcceptTrade, Team: TeamBlue, SeatID: "b4", TargetSeatID: "b2"}); !errors.Is(err, ErrIllegalTrade) {
+		t.Fatalf("declined offer must be gone, got %v", err)
+	}
+	if err := apply(Command{Type: CmdAcceptTrade, Team: TeamBlue, SeatID: "b3", TargetSeatID: "b1"}); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if len(s.TradeOffers) != 0 {
+		t.Fatalf("expected no open offers, got %v", s.TradeOffers)
+	}
+
+	// Window closes on the lobby's trade timer
+	if err := apply(Command{Type: CmdTimeoutAdvance}); err != nil {
+		t.Fatalf("timeout: %v", err)
+	}
+	if s.Phase != PhaseDone || s.Trading {
+		t.Fatalf("want done after trade window, got %v", s.Phase)
+	}
+
+	final := map[string][]int{}
+	for _, seat := range s.Seats[TeamBlue] {
+		final[seat.ID] = seat.Champions
+	}
+	want := map[string][]int{"b1": {3}, "b2": {2}, "b3": {1}, "b4": {4}, "b5": {5}}
+	if !reflect.DeepEqual(final, want) {
+		t.Fatalf("final mapping: got %v, want %v", final, want)
+	}
+	if !reflect.DeepEqual(s.Picks[TeamBlue], []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("pick order must not change on trade, got %v", s.Picks[TeamBlue])
+	}
+	if !reflect.DeepEqual(ReduceFrom(initial, log), s) {
+		t.Fatalf("replay mismatch.\n got: %#v\nwant: %#v", ReduceFrom(initial, log), s)
+	}
+
+	if _, _, err := Apply(s, Command{Type: CmdProposeTrade, Team: TeamBlue, SeatID: "b1", TargetSeatID: "b2"}); !errors.Is(err, ErrNotTrading) {
+		t.Fatalf("want ErrNotTrading after the window, got %v", err)
+	}
+}
+
+func TestTrade_StartNextGameWaitsForWindow(t *testing.T) {
+	s := draftedWithTrade(t)
+	if _, _, err := Apply(s, Command{Type: CmdStartNextGame, Team: TeamBlue}); !errors.Is(err, ErrGameInProgress) {
+		t.Fatalf("want ErrGameInProgress during trades, got %v", err)
+	}
+}
+
+func TestTimeBank_DrainsThenExhausts(t *testing.T) {
+	s := NewEmptyState()
+	s.Rules.TimeBankSec = 10
+	initial := s.Clone()
+
+	var log []Event
+	apply := func(cmd Command) error {
+		events, ns, err := Apply(s, cmd)
+		if err == nil {
+			s = ns
+			log = append(log, events...)
+			if ContainsEvent(events, EvtTurnAdvanced) {
+				s.Cursor++
+			}
+		}
+		return err
+	}
+
+	// Blue's ban clock ran out; 4s of reserve spent before banning
+	if err := apply(Command{Type: CmdStartTimeBank}); err != nil {
+		t.Fatalf("start timebank: %v", err)
+	}
+	if !s.TimeBankActive {
+		t.Fatalf("expected blue on its timebank")
+	}
+	if err := apply(Command{Type: CmdStartTimeBank}); !errors.Is(err, ErrNoTimeBank) {
+		t.Fatalf("want ErrNoTimeBank while already running, got %v", err)
+	}
+	if err := apply(Command{Type: CmdBanChampion, Team: TeamBlue, ChampionID: 1, Millis: 4000}); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+	if s.TimeBankActive || TimeBankLeft(s, TeamBlue) != 6000 || TimeBankLeft(s, TeamRed) != 10000 {
+		t.Fatalf("want blue 6000ms left, red untouched; got %v active=%v", s.TimeBankUsed, s.TimeBankActive)
+	}
+	if log[1].Type != EvtTimeBankStopped || log[1].Millis != 4000 {
+		t.Fatalf("clock event must come before the ban, got %v", log)
+	}
+
+	// Red burns all of its reserve; the timeout settles it as exhausted
+	if err := apply(Command{Type: CmdStartTimeBank}); err != nil {
+		t.Fatalf("start timebank: %v", err)
+	}
+	if err := apply(Command{Type: CmdTimeoutAdvance, Millis: 10012}); err != nil {
+		t.Fatalf("timeout: %v", err)
+	}
+	if !ContainsEvent(log, EvtTimeBankExhausted) || TimeBankLeft(s, TeamRed) != 0 || s.Cursor != 2 {
+		t.Fatalf("want red exhausted and the turn skipped, got used=%v cursor=%d", s.TimeBankUsed, s.Cursor)
+	}
+
+	if got := ReduceFrom(initial, log); !reflect.DeepEqual(got.TimeBankUsed, s.TimeBankUsed) || got.TimeBankActive {
+		t.Fatalf("replay mismatch: got %v", got.TimeBankUsed)
+	}
+
+	// Red has nothing left for its next turn
+	s.Cursor = 3
+	if _, _, err := Apply(s, Command{Type: CmdStartTimeBank}); !errors.Is(err, ErrNoTimeBank) {
+		t.Fatalf("want ErrNoTimeBank for red, got %v", err)
+	}
+}
+
+func TestPause_RejectsTurnsUntilResumed(t *testing.T) {
+	s := NewEmptyState()
+	initial := s.Clone()
+
+	events, s, err := Apply(s, Command{Type: CmdPauseDraft, Millis: 12345})
+	if err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if !s.Paused || s.PausedMs != 12345 {
+		t.Fatalf("want paused with 12345ms frozen, got %v/%d", s.Paused, s.PausedMs)
+	}
+	log := events
+
+	for _, cmd := range []Command{
+		{Type: CmdBanChampion, Team: TeamBlue, ChampionID: 1},
+		{Type: CmdHoverChampion, Team: TeamBlue, SeatID: "b1", ChampionID: 1},
+		{Type: CmdTimeoutAdvance},
+		{Type: CmdPauseDraft},
+	} {
+		if _, _, err := Apply(s, cmd); !errors.Is(err, ErrDraftPaused) {
+			t.Fatalf("%s while paused: want ErrDraftPaused, got %v", cmd.Type, err)
+		}
+	}
+
+	events, s, err = Apply(s, Command{Type: CmdResumeDraft})
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if s.Paused || events[0].Type != EvtDraftResumed || events[0].Millis != 12345 {
+		t.Fatalf("want resume with the frozen 12345ms, got %+v", events)
+	}
+	log = append(log, events...)
+	if _, _, err := Apply(s, Command{Type: CmdResumeDraft}); !errors.Is(err, ErrNotPaused) {
+		t.Fatalf("want ErrNotPaused, got %v", err)
+	}
+	if got := ReduceFrom(initial, log); !reflect.DeepEqual(got, s) {
+		t.Fatalf("replay mismatch.\n got: %#v\nwant: %#v", got, s)
+	}
+	if _, _, err := Apply(s, Command{Type: CmdBanChampion, Team: TeamBlue, ChampionID: 1}); err != nil {
+		t.Fatalf("ban after resume: %v", err)
+	}
+
+	if _, _, err := Apply(NewPreDraftState(), Command{Type: CmdPauseDraft}); !errors.Is(err, ErrDraftNotStarted) {
+		t.Fatalf("want ErrDraftNotStarted before the draft, got %v", err)
+	}
+}
+
+// Property: whatever random mix of commands a lobby sees, folding the events
+// Apply emitted gives exactly the state Apply built along the way.
+func TestReduce_MatchesLiveStateOnRandomCommands(t *testing.T) {
+	ids := fakeRoster{}
+	for id := 1; id <= 40; id++ {
+		ids[id] = true
+	}
+
+	teams := []Team{TeamBlue, TeamRed}
+	seats := []string{"b1", "b2", "b3", "r1", "r2", "r3"}
+	types := []CommandType{
+		CmdJoinSeat, CmdReady, CmdUnready, CmdStartGame, CmdStartNextGame,
+		CmdLockPick, CmdLockPick, CmdLockPick, CmdLockPick, CmdBanChampion, CmdBanChampion, CmdBanChampion,
+		CmdHoverChampion, CmdHoverChampion, CmdTimeoutAdvance, CmdTimeoutAdvance, CmdStartTimeBank, CmdPauseDraft, CmdResumeDraft,
+		CmdProposeTrade, CmdAcceptTrade, CmdDeclineTrade,
+	}
+
+	for seed := uint64(1); seed <= 40; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+
+		initial := NewPreDraftState()
+		initial.Rules.Champions = ids
+		initial.Seed = int64(seed)
+		initial.Series = NewSeries(3)
+		initial.Rules.Fearless = FearlessTeam
+		initial.Rules.TradeTimerSec = 30
+		initial.Rules.TimeBankSec = 5
+		log := []Event{{Type: EvtLobbyCreated, Initial: &initial}}
+		live := Reduce(log)
+
+		for step := range 1500 {
+			seat := seats[r.IntN(len(seats))]
+			cmd := Command{
+				Type:         types[r.IntN(len(types))],
+				Team:         teams[r.IntN(2)],
+				SeatID:       seat,
+				ChampionID:   1 + r.IntN(40),
+				TargetSeatID: seats[r.IntN(len(seats))],
+				Millis:       r.IntN(3000),
+			}
+			if seat[0] == 'b' {
+				cmd.Team = TeamBlue
+			} else {
+				cmd.Team = TeamRed
+			}
+
+			events, ns, err := Apply(live.Clone(), cmd)
+			if err != nil {
+				continue
+			}
+			for _, e := range events {
+				if e.Type == EvtTurnAdvanced {
+					ns.Cursor++ // the lobby's job when it used Apply's state directly
+				}
+			}
+			ns.Phase = DerivePhase(ns)
+			live = ns
+			log = append(log, events...)
+
+			if got := Reduce(log); !reflect.DeepEqual(got, live) {
+				t.Fatalf("seed %d step %d (%s): replay mismatch.\n got: %#v\nwant: %#v", seed, step, cmd.Type, got, live)
+			}
+		}
+	}
+}
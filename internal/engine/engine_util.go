@@ -1,15 +1,35 @@
 package engine
 
+import (
+	"maps"
+	"math/rand/v2"
+	"slices"
+)
+
 func NewEmptyState() State {
 	s := State{
 		Picks:    map[Team][]int{TeamBlue: {}, TeamRed: {}},
 		Bans:     map[Team][]int{TeamBlue: {}, TeamRed: {}},
-		Fearless: map[int]bool{},
+		Fearless: map[Team][]int{TeamBlue: {}, TeamRed: {}},
 		Hover:    map[string]int{},
-		Rules:    Rules{PickTimerSec: 25, BanTimerSec: 25},
+		Rules:    Rules{PickTimerSec: 25, BanTimerSec: 25, Format: FormatTournament},
+		Series:   NewSeries(1),
+		Seats:    map[Team][]Seat{TeamBlue: {}, TeamRed: {}},
+		Ready:    map[Team]bool{TeamBlue: false, TeamRed: false},
 		Cursor:   0,
+
+		TimeBankUsed: map[Team]int{},
 	}
-	s.Phase = DerivePhase(s.Cursor) // Ensure "ban1" shows up on join
+	s.Phase = DerivePhase(s) // Ensure "ban1" shows up on join
+	return s
+}
+
+// NewPreDraftState is what a new lobby starts from: seats open, nobody ready,
+// and no turn clock until StartGame.
+func NewPreDraftState() State {
+	s := NewEmptyState()
+	s.PreDraft = true
+	s.Phase = DerivePhase(s)
 	return s
 }
 
@@ -22,21 +42,69 @@ func ContainsEvent(events []Event, eventType EventType) bool {
 	return false
 }
 
-func DerivePhase(cursor int) Phase {
-	if cursor >= len(GameOrder) {
-		return PhaseDone
-	} else if cursor >= 0 && cursor <= 5 {
-		return PhaseBan1
-	} else if cursor > 5 && cursor <= 11 {
-		return PhasePick1
-	} else if cursor > 11 && cursor <= 15 {
-		return PhaseBan2
-	} else {
-		return PhasePick2
+func DerivePhase(s State) Phase {
+	if s.PreDraft {
+		if bothReady(s) {
+			return PhaseReady
+		}
+		return PhaseLobby
+	}
+	if s.Trading {
+		return PhaseTrade
+	}
+	return s.Rules.ActiveFormat().PhaseAt(s.Cursor)
+}
+
+func isKnownChampion(s State, id int) bool {
+	return id > 0 && s.Rules.champions().Has(id)
+}
+
+// Clone deep-copies the maps and slices so the copy can be mutated without
+// touching the original (Apply appends into the map values it's handed).
+func (s State) Clone() State {
+	c := s
+	c.Picks = cloneTeamSlices(s.Picks)
+	c.Bans = cloneTeamSlices(s.Bans)
+	c.Fearless = cloneTeamSlices(s.Fearless)
+	c.Hover = maps.Clone(s.Hover)
+	c.Series.Score = maps.Clone(s.Series.Score)
+	if s.Seats != nil {
+		c.Seats = cloneSeats(s.Seats)
+	}
+	c.Ready = maps.Clone(s.Ready)
+	c.TradeOffers = slices.Clone(s.TradeOffers)
+	c.TimeBankUsed = maps.Clone(s.TimeBankUsed)
+	return c
+}
+
+func cloneTeamSlices(m map[Team][]int) map[Team][]int {
+	if m == nil {
+		return nil
 	}
+	out := make(map[Team][]int, len(m))
+	for team, ids := range m {
+		out[team] = append([]int{}, ids...)
+	}
+	return out
 }
 
+// chooseRandomLegal picks uniformly from the roster minus anything banned,
+// picked or fearless-locked. The RNG is seeded from the lobby seed, the game
+// index and the cursor, so the same state always yields the same champion:
+// replaying the log through ReduceFrom lands on the auto-pick the live lobby
+// made. With no roster on the Rules it draws from the built-in list, the same
+// one IDs are validated against. Tests still stub this var.
 var chooseRandomLegal = func(s State, team Team) (int, bool) {
-	// real impl later; for tests, you’ll stub this.
-	return 0, false
+	legal := []int{}
+	for _, id := range s.Rules.champions().IDs() {
+		if canPick(s, team, id) {
+			legal = append(legal, id)
+		}
+	}
+	if len(legal) == 0 {
+		return 0, false
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(s.Seed), uint64(s.Series.GameIndex)<<32|uint64(s.Cursor)))
+	return legal[rng.IntN(len(legal))], true
 }
@@ -0,0 +1,21 @@
+package engine
+
+import "time"
+
+// LogEntry is one event as a lobby recorded it. Folding every entry's Event
+// in order (see Reduce) gives back the lobby's state.
+type LogEntry struct {
+	Ordinal int // 1-based position in the log
+	Batch   int // entries produced by the same command share a batch
+	At      time.Time
+	Event   Event
+}
+
+// Events strips a log down to the events Reduce takes.
+func Events(log []LogEntry) []Event {
+	out := make([]Event, len(log))
+	for i, entry := range log {
+		out[i] = entry.Event
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package engine
+
+import "errors"
+
+var ErrDraftPaused = errors.New("draft is paused")
+var ErrNotPaused = errors.New("draft is not paused")
+
+// applyPause freezes or unfreezes a running draft (or trade window). The lobby
+// owns the clock, so it passes what's left on it in cmd.Millis when pausing
+// and gets the same number back on the resume event.
+func applyPause(s State, cmd Command) ([]Event, State, error) {
+	var e Event
+	switch cmd.Type {
+	case CmdPauseDraft:
+		if s.PreDraft {
+			return nil, s, ErrDraftNotStarted
+		}
+		if _, done := currentStep(s); done && !s.Trading {
+			return nil, s, ErrGameAlreadyCompleted
+		}
+		if s.Paused {
+			return nil, s, ErrDraftPaused
+		}
+		e = Event{Type: EvtDraftPaused, Millis: max(cmd.Millis, 0)}
+	case CmdResumeDraft:
+		if !s.Paused {
+			return nil, s, ErrNotPaused
+		}
+		e = Event{Type: EvtDraftResumed, Millis: s.PausedMs}
+	}
+	return []Event{e}, reducePause(s, e), nil
+}
+
+func reducePause(s State, e Event) State {
+	switch e.Type {
+	case EvtDraftPaused:
+		s.Paused = true
+		s.PausedMs = e.Millis
+	case EvtDraftResumed:
+		s.Paused = false
+		s.PausedMs = 0
+	}
+	return s
+}
@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"maps"
+	"slices"
+)
+
+// MaxSeatsPerTeam is the number of players a side can seat.
+const MaxSeatsPerTeam = 5
+
+// Seat is one player on a team. PickSlots index into that team's picks
+// (0 = the team's first pick) and Champions is what the seat ended up with.
+type Seat struct {
+	ID        string
+	Name      string
+	Role      string
+	PickSlots []int
+	Champions []int
+}
+
+// Captain is the first seat to join a team; only they ready the team up.
+func Captain(s State, team Team) (Seat, bool) {
+	if len(s.Seats[team]) == 0 {
+		return Seat{}, false
+	}
+	return s.Seats[team][0], true
+}
+
+func findSeat(s State, seatID string) (Team, int, bool) {
+	for _, team := range []Team{TeamBlue, TeamRed} {
+		if i := slices.IndexFunc(s.Seats[team], func(seat Seat) bool { return seat.ID == seatID }); i >= 0 {
+			return team, i, true
+		}
+	}
+	return "", -1, false
+}
+
+func bothReady(s State) bool {
+	return s.Ready[TeamBlue] && s.Ready[TeamRed]
+}
+
+func applyPreDraft(s State, cmd Command) ([]Event, State, error) {
+	if !s.PreDraft {
+		return nil, s, ErrDraftStarted
+	}
+
+	var events []Event
+	switch cmd.Type {
+	case CmdJoinSeat:
+		if cmd.Team != TeamBlue && cmd.Team != TeamRed {
+			return nil, s, ErrInvalidTeam
+		}
+		if _, _, taken := findSeat(s, cmd.SeatID); taken || cmd.SeatID == "" {
+			return nil, s, ErrSeatTaken
+		}
+		if len(s.Seats[cmd.Team]) >= MaxSeatsPerTeam {
+			return nil, s, ErrTeamFull
+		}
+		events = []Event{{Type: EvtSeatJoined, Team: cmd.Team, SeatID: cmd.SeatID, Name: cmd.Name, Role: cmd.Role}}
+
+	case CmdReady, CmdUnready:
+		if captain, ok := Captain(s, cmd.Team); !ok || captain.ID != cmd.SeatID {
+			return nil, s, ErrNotCaptain
+		}
+		evt := EvtTeamReady
+		if cmd.Type == CmdUnready {
+			evt = EvtTeamUnready
+		}
+		events = []Event{{Type: evt, Team: cmd.Team, SeatID: cmd.SeatID}}
+
+	case CmdStartGame:
+		if !bothReady(s) {
+			return nil, s, ErrNotReady
+		}
+		events = []Event{{Type: EvtDraftStarted}, {Type: EvtTimerStarted}}
+
+	default:
+		return nil, s, ErrUnsupportedCommand
+	}
+
+	newState := s
+	for _, e := range events {
+		newState = reducePreDraft(newState, e)
+	}
+	newState.Phase = DerivePhase(newState)
+	return events, newState, nil
+}
+
+// reducePreDraft folds one pre-draft event into s without touching the
+// caller's maps, so Apply and ReduceFrom share the same logic.
+func reducePreDraft(s State, e Event) State {
+	switch e.Type {
+	case EvtSeatJoined:
+		s.Seats = cloneSeats(s.Seats)
+		s.Seats[e.Team] = append(s.Seats[e.Team], Seat{ID: e.SeatID, Name: e.Name, Role: e.Role})
+	case EvtTeamReady, EvtTeamUnready:
+		s.Ready = maps.Clone(s.Ready)
+		if s.Ready == nil {
+			s.Ready = map[Team]bool{}
+		}
+		s.Ready[e.Team] = e.Type == EvtTeamReady
+	case EvtDraftStarted:
+		s.PreDraft = false
+		s = assignPickSlots(s)
+	}
+	return s
+}
+
+// assignPickSlots deals each team's pick slots out to its seats in join
+// order, wrapping around if a team has fewer seats than picks.
+func assignPickSlots(s State) State {
+	s.Seats = cloneSeats(s.Seats)
+	for team, seats := range s.Seats {
+		if len(seats) == 0 {
+			continue
+		}
+		for i := range seats {
+			seats[i].PickSlots = nil
+		}
+		for slot := range picksPerTeam(s.Rules.ActiveFormat(), team) {
+			owner := &seats[slot%len(seats)]
+			owner.PickSlots = append(owner.PickSlots, slot)
+		}
+	}
+	return s
+}
+
+func picksPerTeam(f DraftFormat, team Team) int {
+	n := 0
+	for _, step := range f.Steps {
+		if step.Team == team && step.Action == ActionPick {
+			n++
+		}
+	}
+	return n
+}
+
+// pickSlotOwner is the seat that owns team's next pick. ok is false when the
+// team has no roster, in which case anyone on the team may pick.
+func pickSlotOwner(s State, team Team) (Seat, bool) {
+	slot := len(s.Picks[team])
+	for _, seat := range s.Seats[team] {
+		if slices.Contains(seat.PickSlots, slot) {
+			return seat, true
+		}
+	}
+	return Seat{}, false
+}
+
+// turnSeat is whose hover counts when the clock runs out: the pick slot's
+// owner on picks, the captain on bans.
+func turnSeat(s State, step TurnStep) string {
+	if step.Action == ActionPick {
+		if owner, ok := pickSlotOwner(s, step.Team); ok {
+			return owner.ID
+		}
+		return ""
+	}
+	if captain, ok := Captain(s, step.Team); ok {
+		return captain.ID
+	}
+	return ""
+}
+
+func seatOnTeam(s State, team Team, seatID string) bool {
+	return slices.ContainsFunc(s.Seats[team], func(seat Seat) bool { return seat.ID == seatID })
+}
+
+// recordSeatPick credits a locked champion to the seat that picked it.
+func recordSeatPick(s State, team Team, seatID string, id int) State {
+	i := slices.IndexFunc(s.Seats[team], func(seat Seat) bool { return seat.ID == seatID })
+	if i < 0 {
+		return s
+	}
+	s.Seats = cloneSeats(s.Seats)
+	s.Seats[team][i].Champions = append(slices.Clone(s.Seats[team][i].Champions), id)
+	return s
+}
+
+func cloneSeats(m map[Team][]Seat) map[Team][]Seat {
+	out := make(map[Team][]Seat, 2)
+	for team, seats := range m {
+		out[team] = make([]Seat, len(seats))
+		for i, seat := range seats {
+			seat.PickSlots = slices.Clone(seat.PickSlots)
+			seat.Champions = slices.Clone(seat.Champions)
+			out[team][i] = seat
+		}
+	}
+	return out
+}
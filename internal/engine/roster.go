@@ -0,0 +1,46 @@
+package engine
+
+import "slices"
+
+// Roster is the set of champions drafts may use; *catalog.Catalog implements it.
+type Roster interface {
+	Has(id int) bool
+	IDs() []int
+}
+
+// builtinChampionIDs is a fallback only, for a server run without a champion
+// catalog: IDs are validated against it and auto-picks drawn from it. It's a
+// hand-copied snapshot of Data Dragon keys and nothing keeps it current, so
+// newer champions are missing. With a catalog loaded the roster is built
+// from the catalog instead (see Rules.Champions) and this list is unused.
+var builtinChampionIDs = []int{
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+	21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40,
+	41, 42, 43, 44, 45, 48, 50, 51, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
+	67, 68, 69, 72, 74, 75, 76, 77, 78, 79, 80, 81, 82, 83, 84, 85, 86, 89, 90, 91,
+	92, 96, 98, 99, 101, 102, 103, 104, 105, 106, 107, 110, 111, 112, 113, 114, 115, 117, 119, 120,
+	121, 122, 126, 127, 131, 133, 134, 136, 141, 142, 143, 145, 147, 150, 154, 157, 161, 163, 164, 166,
+	200, 201, 202, 203, 221, 222, 223, 233, 234, 235, 236, 238, 240, 245, 246, 254, 266, 267, 268, 350,
+	360, 412, 420, 421, 427, 429, 432, 497, 498, 516, 517, 518, 523, 526, 555, 711, 777, 799, 800, 875,
+	876, 887, 888, 893, 895, 897, 901, 902, 910, 950,
+}
+
+// champions is the roster r's draft is checked against.
+func (r Rules) champions() Roster {
+	if r.Champions == nil {
+		return builtinRoster
+	}
+	return r.Champions
+}
+
+// idRoster is a Roster over a sorted list of IDs.
+type idRoster []int
+
+var builtinRoster = idRoster(builtinChampionIDs)
+
+func (r idRoster) Has(id int) bool {
+	_, ok := slices.BinarySearch(r, id)
+	return ok
+}
+
+func (r idRoster) IDs() []int { return r }
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"maps"
+	"slices"
+)
+
+// Series tracks a best-of run of drafts in one lobby. Unless fearless is off,
+// every champion a team picked in an earlier game is carried into
+// State.Fearless under that team; Rules.Fearless decides who it locks out.
+type Series struct {
+	BestOf    int          // 1, 3 or 5
+	GameIndex int          // 0-based index of the game being drafted
+	Score     map[Team]int // games won so far
+}
+
+func NewSeries(bestOf int) Series {
+	if bestOf < 1 {
+		bestOf = 1
+	}
+	return Series{BestOf: bestOf, Score: map[Team]int{TeamBlue: 0, TeamRed: 0}}
+}
+
+// Winner is the team that has clinched the series, if any.
+func (sr Series) Winner() (Team, bool) {
+	for _, team := range []Team{TeamBlue, TeamRed} {
+		if sr.Score[team] > sr.BestOf/2 {
+			return team, true
+		}
+	}
+	return "", false
+}
+
+func applyStartNextGame(s State, cmd Command) ([]Event, State, error) {
+	if _, done := currentStep(s); !done || s.Trading {
+		return nil, s, ErrGameInProgress
+	}
+	if _, over := s.Series.Winner(); over {
+		return nil, s, ErrSeriesOver
+	}
+	if cmd.Team != TeamBlue && cmd.Team != TeamRed {
+		return nil, s, ErrInvalidTeam
+	}
+
+	series := recordWin(s.Series, cmd.Team)
+	if _, over := series.Winner(); over {
+		newState := s
+		newState.Series = series
+		return []Event{{Type: EvtSeriesCompleted, Team: cmd.Team}}, newState, nil
+	}
+
+	return []Event{{Type: EvtNextGameStarted, Team: cmd.Team}}, startNextGame(s, cmd.Team), nil
+}
+
+func recordWin(sr Series, winner Team) Series {
+	sr.Score = maps.Clone(sr.Score)
+	if sr.Score == nil {
+		sr.Score = map[Team]int{}
+	}
+	sr.Score[winner]++
+	return sr
+}
+
+// startNextGame credits the previous game to winner and resets the board for
+// a fresh draft, locking last game's picks if the lobby plays fearless. With
+// seats on both sides the next game goes back to pre-draft: captains ready
+// up again and nothing ticks until StartGame. A seatless draft (started
+// without a pre-draft) has nobody to ready up, so it goes straight to ban1.
+func startNextGame(s State, winner Team) State {
+	next := s
+	next.Series = recordWin(s.Series, winner)
+	next.Series.GameIndex++
+
+	next.Fearless = cloneTeamSlices(s.Fearless)
+	if next.Fearless == nil {
+		next.Fearless = map[Team][]int{TeamBlue: {}, TeamRed: {}}
+	}
+	if s.Rules.Fearless == FearlessHard || s.Rules.Fearless == FearlessTeam {
+		for team, ids := range s.Picks {
+			for _, id := range ids {
+				if !slices.Contains(next.Fearless[team], id) {
+					next.Fearless[team] = append(next.Fearless[team], id)
+				}
+			}
+		}
+	}
+
+	next.Cursor = 0
+	next.Picks = map[Team][]int{TeamBlue: {}, TeamRed: {}}
+	next.Bans = map[Team][]int{TeamBlue: {}, TeamRed: {}}
+	next.Hover = map[string]int{}
+	next.Trading = false
+	next.TradeOffers = nil
+	next.TimeBankUsed = map[Team]int{} // reserve refills every game
+	next.TimeBankActive = false
+	next.Seats = cloneSeats(s.Seats)
+	for _, seats := range next.Seats {
+		for i := range seats {
+			seats[i].Champions = nil
+		}
+	}
+	_, blue := Captain(s, TeamBlue)
+	_, red := Captain(s, TeamRed)
+	next.PreDraft = blue && red
+	next.Ready = map[Team]bool{TeamBlue: false, TeamRed: false}
+	next.Phase = DerivePhase(next)
+	return next
+}
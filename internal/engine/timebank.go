@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"errors"
+	"maps"
+)
+
+var ErrNoTimeBank = errors.New("no timebank left")
+
+// TimeBankLeft is how many ms of reserve team still has this game.
+func TimeBankLeft(s State, team Team) int {
+	left := s.Rules.TimeBankSec*1000 - s.TimeBankUsed[team]
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+func applyStartTimeBank(s State) ([]Event, State, error) {
+	if s.PreDraft {
+		return nil, s, ErrDraftNotStarted
+	}
+	step, done := currentStep(s)
+	if done || s.Trading {
+		return nil, s, ErrGameAlreadyCompleted
+	}
+	left := TimeBankLeft(s, step.Team)
+	if s.TimeBankActive || left == 0 {
+		return nil, s, ErrNoTimeBank
+	}
+	e := Event{Type: EvtTimeBankStarted, Team: step.Team, Millis: left}
+	return []Event{e}, reduceTimeBank(s, e), nil
+}
+
+// applyOnTimeBank runs a turn command for a team that's on its reserve and
+// puts the clock event in front of the turn's own events.
+func applyOnTimeBank(s State, cmd Command) ([]Event, State, error) {
+	step, _ := currentStep(s)
+	clock := Event{Type: EvtTimeBankStopped, Team: step.Team, Millis: cmd.Millis}
+	if cmd.Millis >= TimeBankLeft(s, step.Team) {
+		clock = Event{Type: EvtTimeBankExhausted, Team: step.Team, Millis: TimeBankLeft(s, step.Team)}
+	}
+
+	off := s
+	off.TimeBankActive = false
+	events, newState, err := Apply(off, cmd)
+	if err != nil {
+		return nil, s, err
+	}
+	return append([]Event{clock}, events...), reduceTimeBank(newState, clock), nil
+}
+
+func reduceTimeBank(s State, e Event) State {
+	switch e.Type {
+	case EvtTimeBankStarted:
+		s.TimeBankActive = true
+	case EvtTimeBankStopped, EvtTimeBankExhausted:
+		s.TimeBankActive = false
+		s.TimeBankUsed = maps.Clone(s.TimeBankUsed)
+		if s.TimeBankUsed == nil {
+			s.TimeBankUsed = map[Team]int{}
+		}
+		s.TimeBankUsed[e.Team] += e.Millis
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package engine
+
+import "slices"
+
+// TradeOffer is From asking To (same team) to swap champions.
+type TradeOffer struct {
+	Team Team
+	From string
+	To   string
+}
+
+// draftEndEvent is what follows the last pick: a trade window if the rules
+// have one, otherwise the game is done.
+func draftEndEvent(s State) Event {
+	if s.Rules.TradeTimerSec > 0 {
+		return Event{Type: EvtTradePhaseStarted}
+	}
+	return Event{Type: EvtGameCompleted}
+}
+
+func applyTrade(s State, cmd Command) ([]Event, State, error) {
+	if !s.Trading {
+		return nil, s, ErrNotTrading
+	}
+
+	var events []Event
+	switch cmd.Type {
+	case CmdTimeoutAdvance:
+		// Trade window closed
+		events = []Event{{Type: EvtGameCompleted}}
+
+	case CmdProposeTrade:
+		if cmd.SeatID == cmd.TargetSeatID {
+			return nil, s, ErrIllegalTrade
+		}
+		if !seatOnTeam(s, cmd.Team, cmd.SeatID) || !seatOnTeam(s, cmd.Team, cmd.TargetSeatID) {
+			return nil, s, ErrNotOnTeam
+		}
+		// Swaps are one champion for one champion
+		if len(seatChampions(s, cmd.Team, cmd.SeatID)) != 1 || len(seatChampions(s, cmd.Team, cmd.TargetSeatID)) != 1 {
+			return nil, s, ErrIllegalTrade
+		}
+		if findOffer(s, cmd.Team, cmd.SeatID, cmd.TargetSeatID) >= 0 || findOffer(s, cmd.Team, cmd.TargetSeatID, cmd.SeatID) >= 0 {
+			return nil, s, ErrIllegalTrade
+		}
+		events = []Event{{Type: EvtTradeProposed, Team: cmd.Team, SeatID: cmd.SeatID, TargetSeatID: cmd.TargetSeatID}}
+
+	case CmdAcceptTrade:
+		// Only the seat the offer was made to can accept it
+		if findOffer(s, cmd.Team, cmd.TargetSeatID, cmd.SeatID) < 0 {
+			return nil, s, ErrIllegalTrade
+		}
+		events = []Event{{Type: EvtTradeAccepted, Team: cmd.Team, SeatID: cmd.SeatID, TargetSeatID: cmd.TargetSeatID}}
+
+	case CmdDeclineTrade:
+		if findOffer(s, cmd.Team, cmd.TargetSeatID, cmd.SeatID) < 0 && findOffer(s, cmd.Team, cmd.SeatID, cmd.TargetSeatID) < 0 {
+			return nil, s, ErrIllegalTrade
+		}
+		events = []Event{{Type: EvtTradeDeclined, Team: cmd.Team, SeatID: cmd.SeatID, TargetSeatID: cmd.TargetSeatID}}
+
+	default:
+		return nil, s, ErrUnsupportedCommand
+	}
+
+	newState := s
+	for _, e := range events {
+		newState = reduceTrade(newState, e)
+	}
+	newState.Phase = DerivePhase(newState)
+	return events, newState, nil
+}
+
+// reduceTrade folds one trade event into s without touching the caller's
+// slices. EvtGameCompleted lands here too since it closes the window.
+func reduceTrade(s State, e Event) State {
+	switch e.Type {
+	case EvtTradePhaseStarted:
+		s.Trading = true
+		s.TradeOffers = nil
+
+	case EvtTradeProposed:
+		s.TradeOffers = append(slices.Clone(s.TradeOffers), TradeOffer{Team: e.Team, From: e.SeatID, To: e.TargetSeatID})
+
+	case EvtTradeAccepted:
+		s.Seats = cloneSeats(s.Seats)
+		seats := s.Seats[e.Team]
+		a := slices.IndexFunc(seats, func(seat Seat) bool { return seat.ID == e.SeatID })
+		b := slices.IndexFunc(seats, func(seat Seat) bool { return seat.ID == e.TargetSeatID })
+		if a >= 0 && b >= 0 {
+			seats[a].Champions, seats[b].Champions = seats[b].Champions, seats[a].Champions
+		}
+		// Both seats hold something new now, so any other offer touching them is stale
+		s.TradeOffers = slices.DeleteFunc(slices.Clone(s.TradeOffers), func(o TradeOffer) bool {
+			return o.Team == e.Team && (o.From == e.SeatID || o.To == e.SeatID || o.From == e.TargetSeatID || o.To == e.TargetSeatID)
+		})
+
+	case EvtTradeDeclined:
+		s.TradeOffers = slices.DeleteFunc(slices.Clone(s.TradeOffers), func(o TradeOffer) bool {
+			return o.Team == e.Team && (o.From == e.SeatID && o.To == e.TargetSeatID || o.From == e.TargetSeatID && o.To == e.SeatID)
+		})
+
+	case EvtGameCompleted:
+		s.Trading = false
+		s.TradeOffers = nil
+	}
+	return s
+}
+
+func findOffer(s State, team Team, from, to string) int {
+	return slices.IndexFunc(s.TradeOffers, func(o TradeOffer) bool {
+		return o.Team == team && o.From == from && o.To == to
+	})
+}
+
+func seatChampions(s State, team Team, seatID string) []int {
+	for _, seat := range s.Seats[team] {
+		if seat.ID == seatID {
+			return seat.Champions
+		}
+	}
+	return nil
+}
@@ -1,28 +1,239 @@
 package engine
 
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrInvalidFormat = errors.New("invalid draft format")
+
+// PhaseSpan labels the cursor range [Start, End) of a draft format with a phase.
+type PhaseSpan struct {
+	Phase Phase
+	Start int
+	End   int
+}
+
+// DraftFormat is an ordered list of turns plus the phase boundaries over them.
+// Apply, DerivePhase and the lobby timer all walk whatever format the lobby's
+// Rules carry instead of a single hard-coded order.
+type DraftFormat struct {
+	ID     string
+	Name   string
+	Steps  []TurnStep
+	Phases []PhaseSpan
+}
+
+// PhaseAt maps a cursor to its phase label. Anything past the last step is done.
+func (f DraftFormat) PhaseAt(cursor int) Phase {
+	if cursor >= len(f.Steps) {
+		return PhaseDone
+	}
+	for _, span := range f.Phases {
+		if cursor >= span.Start && cursor < span.End {
+			return span.Phase
+		}
+	}
+	return PhaseDone
+}
+
+// Standard tournament draft: 3 bans, 3 picks, 2 bans, 2 picks per team.
 var GameOrder = []TurnStep{
-    // Ban Phase 1
-    {Team: TeamBlue, Action: ActionBan}, // 0
-    {Team: TeamRed,  Action: ActionBan}, // 1
-    {Team: TeamBlue, Action: ActionBan}, // 2
-    {Team: TeamRed,  Action: ActionBan}, // 3
-    {Team: TeamBlue, Action: ActionBan}, // 4
-    {Team: TeamRed,  Action: ActionBan}, // 5
-    // Pick Phase 1
-    {Team: TeamBlue, Action: ActionPick}, // 6
-    {Team: TeamRed,  Action: ActionPick}, // 7
-    {Team: TeamRed,  Action: ActionPick}, // 8
-    {Team: TeamBlue, Action: ActionPick}, // 9
-    {Team: TeamBlue, Action: ActionPick}, // 10
-    {Team: TeamRed,  Action: ActionPick}, // 11
-    // Ban Phase 2
-    {Team: TeamRed,  Action: ActionBan}, // 12
-    {Team: TeamBlue, Action: ActionBan}, // 13
-    {Team: TeamRed,  Action: ActionBan}, // 14
-    {Team: TeamBlue, Action: ActionBan}, // 15
-    // Pick Phase 2
-    {Team: TeamRed,  Action: ActionPick}, // 16
-    {Team: TeamBlue, Action: ActionPick}, // 17
-    {Team: TeamBlue, Action: ActionPick}, // 18
-    {Team: TeamRed,  Action: ActionPick}, // 19
-}
\ No newline at end of file
+	// Ban Phase 1
+	{Team: TeamBlue, Action: ActionBan}, // 0
+	{Team: TeamRed, Action: ActionBan},  // 1
+	{Team: TeamBlue, Action: ActionBan}, // 2
+	{Team: TeamRed, Action: ActionBan},  // 3
+	{Team: TeamBlue, Action: ActionBan}, // 4
+	{Team: TeamRed, Action: ActionBan},  // 5
+	// Pick Phase 1
+	{Team: TeamBlue, Action: ActionPick}, // 6
+	{Team: TeamRed, Action: ActionPick},  // 7
+	{Team: TeamRed, Action: ActionPick},  // 8
+	{Team: TeamBlue, Action: ActionPick}, // 9
+	{Team: TeamBlue, Action: ActionPick}, // 10
+	{Team: TeamRed, Action: ActionPick},  // 11
+	// Ban Phase 2
+	{Team: TeamRed, Action: ActionBan},  // 12
+	{Team: TeamBlue, Action: ActionBan}, // 13
+	{Team: TeamRed, Action: ActionBan},  // 14
+	{Team: TeamBlue, Action: ActionBan}, // 15
+	// Pick Phase 2
+	{Team: TeamRed, Action: ActionPick},  // 16
+	{Team: TeamBlue, Action: ActionPick}, // 17
+	{Team: TeamBlue, Action: ActionPick}, // 18
+	{Team: TeamRed, Action: ActionPick},  // 19
+}
+
+var FormatTournament = DraftFormat{
+	ID:    "tournament",
+	Name:  "Tournament (5 bans)",
+	Steps: GameOrder,
+	Phases: []PhaseSpan{
+		{Phase: PhaseBan1, Start: 0, End: 6},
+		{Phase: PhasePick1, Start: 6, End: 12},
+		{Phase: PhaseBan2, Start: 12, End: 16},
+		{Phase: PhasePick2, Start: 16, End: 20},
+	},
+}
+
+// Old 3-ban draft: three bans each, then all ten picks in snake order.
+var FormatLegacy3Ban = DraftFormat{
+	ID:   "legacy3",
+	Name: "Legacy (3 bans)",
+	Steps: []TurnStep{
+		// Ban Phase 1
+		{Team: TeamBlue, Action: ActionBan}, // 0
+		{Team: TeamRed, Action: ActionBan},  // 1
+		{Team: TeamBlue, Action: ActionBan}, // 2
+		{Team: TeamRed, Action: ActionBan},  // 3
+		{Team: TeamBlue, Action: ActionBan}, // 4
+		{Team: TeamRed, Action: ActionBan},  // 5
+		// Pick Phase 1
+		{Team: TeamBlue, Action: ActionPick}, // 6
+		{Team: TeamRed, Action: ActionPick},  // 7
+		{Team: TeamRed, Action: ActionPick},  // 8
+		{Team: TeamBlue, Action: ActionPick}, // 9
+		{Team: TeamBlue, Action: ActionPick}, // 10
+		{Team: TeamRed, Action: ActionPick},  // 11
+		{Team: TeamRed, Action: ActionPick},  // 12
+		{Team: TeamBlue, Action: ActionPick}, // 13
+		{Team: TeamBlue, Action: ActionPick}, // 14
+		{Team: TeamRed, Action: ActionPick},  // 15
+	},
+	Phases: []PhaseSpan{
+		{Phase: PhaseBan1, Start: 0, End: 6},
+		{Phase: PhasePick1, Start: 6, End: 16},
+	},
+}
+
+// Clash: all ten bans up front, then snake picks.
+var FormatClash = DraftFormat{
+	ID:   "clash",
+	Name: "Clash",
+	Steps: []TurnStep{
+		// Ban Phase 1
+		{Team: TeamBlue, Action: ActionBan}, // 0
+		{Team: TeamRed, Action: ActionBan},  // 1
+		{Team: TeamBlue, Action: ActionBan}, // 2
+		{Team: TeamRed, Action: ActionBan},  // 3
+		{Team: TeamBlue, Action: ActionBan}, // 4
+		{Team: TeamRed, Action: ActionBan},  // 5
+		{Team: TeamBlue, Action: ActionBan}, // 6
+		{Team: TeamRed, Action: ActionBan},  // 7
+		{Team: TeamBlue, Action: ActionBan}, // 8
+		{Team: TeamRed, Action: ActionBan},  // 9
+		// Pick Phase 1
+		{Team: TeamBlue, Action: ActionPick}, // 10
+		{Team: TeamRed, Action: ActionPick},  // 11
+		{Team: TeamRed, Action: ActionPick},  // 12
+		{Team: TeamBlue, Action: ActionPick}, // 13
+		{Team: TeamBlue, Action: ActionPick}, // 14
+		{Team: TeamRed, Action: ActionPick},  // 15
+		{Team: TeamRed, Action: ActionPick},  // 16
+		{Team: TeamBlue, Action: ActionPick}, // 17
+		{Team: TeamBlue, Action: ActionPick}, // 18
+		{Team: TeamRed, Action: ActionPick},  // 19
+	},
+	Phases: []PhaseSpan{
+		{Phase: PhaseBan1, Start: 0, End: 10},
+		{Phase: PhasePick1, Start: 10, End: 20},
+	},
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]DraftFormat{
+		FormatTournament.ID: FormatTournament,
+		FormatLegacy3Ban.ID: FormatLegacy3Ban,
+		FormatClash.ID:      FormatClash,
+	}
+)
+
+// LookupFormat finds a format by ID. An empty ID means the tournament default.
+func LookupFormat(id string) (DraftFormat, bool) {
+	if id == "" {
+		return FormatTournament, true
+	}
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formats[id]
+	return f, ok
+}
+
+// RegisterFormat validates a custom format and makes it available by ID.
+// Built-ins and previously registered formats can't be replaced.
+func RegisterFormat(f DraftFormat) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if _, exists := formats[f.ID]; exists {
+		return fmt.Errorf("%w: duplicate id %q", ErrInvalidFormat, f.ID)
+	}
+	formats[f.ID] = f
+	return nil
+}
+
+// Validate checks that a format is playable: a known team and action on every
+// step, phases that cover the steps back to back with no empty ones, ban/pick
+// labels that match the steps inside them, and an equal number of picks per team.
+func (f DraftFormat) Validate() error {
+	if f.ID == "" {
+		return fmt.Errorf("%w: missing id", ErrInvalidFormat)
+	}
+	if len(f.Steps) == 0 {
+		return fmt.Errorf("%w %q: no steps", ErrInvalidFormat, f.ID)
+	}
+
+	picks := map[Team]int{}
+	for i, step := range f.Steps {
+		if step.Team != TeamBlue && step.Team != TeamRed {
+			return fmt.Errorf("%w %q: step %d has unknown team %q", ErrInvalidFormat, f.ID, i, step.Team)
+		}
+		switch step.Action {
+		case ActionPick:
+			picks[step.Team]++
+		case ActionBan:
+		default:
+			return fmt.Errorf("%w %q: step %d has unknown action %q", ErrInvalidFormat, f.ID, i, step.Action)
+		}
+	}
+	if picks[TeamBlue] == 0 || picks[TeamBlue] != picks[TeamRed] {
+		return fmt.Errorf("%w %q: uneven picks (blue %d, red %d)", ErrInvalidFormat, f.ID, picks[TeamBlue], picks[TeamRed])
+	}
+
+	next := 0
+	for _, span := range f.Phases {
+		want, ok := phaseActions[span.Phase]
+		if !ok {
+			return fmt.Errorf("%w %q: unknown phase label %q", ErrInvalidFormat, f.ID, span.Phase)
+		}
+		if span.Start != next || span.End <= span.Start {
+			return fmt.Errorf("%w %q: phase %q must start at %d and be non-empty", ErrInvalidFormat, f.ID, span.Phase, next)
+		}
+		if span.End > len(f.Steps) {
+			return fmt.Errorf("%w %q: phase %q runs past the last step", ErrInvalidFormat, f.ID, span.Phase)
+		}
+		for i := span.Start; i < span.End; i++ {
+			if f.Steps[i].Action != want {
+				return fmt.Errorf("%w %q: step %d is a %s inside phase %q", ErrInvalidFormat, f.ID, i, f.Steps[i].Action, span.Phase)
+			}
+		}
+		next = span.End
+	}
+	if next != len(f.Steps) {
+		return fmt.Errorf("%w %q: phases cover %d of %d steps", ErrInvalidFormat, f.ID, next, len(f.Steps))
+	}
+	return nil
+}
+
+// Labels a format may use, and the action every step inside them must be.
+var phaseActions = map[Phase]Action{
+	PhaseBan1:  ActionBan,
+	PhasePick1: ActionPick,
+	PhaseBan2:  ActionBan,
+	PhasePick2: ActionPick,
+}
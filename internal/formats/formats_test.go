@@ -0,0 +1,106 @@
+package formats
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+)
+
+func writeFile(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDir_ReadsJSONAndYAML(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.json", `{
+		"id": "pick-first",
+		"phases": [
+			{"label": "pick1", "steps": [{"team": "blue", "action": "pick"}, {"team": "red", "action": "pick"}]},
+			{"label": "ban1", "steps": [{"team": "red", "action": "ban"}, {"team": "blue", "action": "ban"}]}
+		]
+	}`)
+	writeFile(t, dir, "b.yaml", `
+id: double-ban
+name: Double ban
+phases:
+  - label: ban1
+    steps:
+      - {team: blue, action: ban}
+      - {team: blue, action: ban}
+      - {team: red, action: ban}
+      - {team: red, action: ban}
+  - label: pick1
+    steps:
+      - {team: blue, action: pick}
+      - {team: red, action: pick}
+`)
+	writeFile(t, dir, "notes.txt", "ignored")
+
+	got, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 formats, got %d", len(got))
+	}
+
+	pickFirst := got[0]
+	if pickFirst.ID != "pick-first" || pickFirst.Name != "pick-first" {
+		t.Fatalf("unexpected first format: %+v", pickFirst)
+	}
+	if phase := pickFirst.PhaseAt(2); phase != engine.PhaseBan1 {
+		t.Fatalf("want ban1 at cursor 2, got %v", phase)
+	}
+
+	doubleBan := got[1]
+	if len(doubleBan.Steps) != 6 || doubleBan.Phases[1] != (engine.PhaseSpan{Phase: engine.PhasePick1, Start: 4, End: 6}) {
+		t.Fatalf("unexpected yaml format: %+v", doubleBan)
+	}
+}
+
+func TestLoadFile_RejectsInvalidFormats(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "uneven picks",
+			body: `{"id": "x", "phases": [{"label": "pick1", "steps": [{"team": "blue", "action": "pick"}]}]}`,
+		},
+		{
+			name: "empty phase",
+			body: `{"id": "x", "phases": [
+				{"label": "ban1", "steps": []},
+				{"label": "pick1", "steps": [{"team": "blue", "action": "pick"}, {"team": "red", "action": "pick"}]}
+			]}`,
+		},
+		{
+			name: "unknown label",
+			body: `{"id": "x", "phases": [{"label": "pick9", "steps": [{"team": "blue", "action": "pick"}, {"team": "red", "action": "pick"}]}]}`,
+		},
+		{
+			name: "ban inside pick phase",
+			body: `{"id": "x", "phases": [{"label": "pick1", "steps": [
+				{"team": "blue", "action": "pick"}, {"team": "red", "action": "ban"}, {"team": "red", "action": "pick"}
+			]}]}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "f.json", tc.body)
+
+			_, err := LoadFile(filepath.Join(dir, "f.json"))
+			if !errors.Is(err, engine.ErrInvalidFormat) {
+				t.Fatalf("want ErrInvalidFormat, got %v", err)
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package httpapi
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 
+	"github.com/DoyleJ11/lol-draft-backend/internal/catalog"
 	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
 	"github.com/DoyleJ11/lol-draft-backend/internal/hub"
 	"github.com/DoyleJ11/lol-draft-backend/internal/lobby"
@@ -28,6 +31,59 @@ func GenerateCode() (string, error) {
 
 func CreateLobby(h *hub.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Body is optional; an empty one gets a Bo1 on the tournament format.
+		var req struct {
+			Format   string `json:"format"`
+			BestOf   int    `json:"best_of"`
+			Fearless string `json:"fearless"` // "off" | "hard" | "team"
+
+			TradeTimerSec int `json:"trade_timer_sec"` // 0 = no post-draft trade phase
+			TimeBankSec   int `json:"time_bank_sec"`   // per-team reserve per game; 0 = none
+
+			SpectatorDelaySec int  `json:"spectator_delay_sec"` // how far behind ?role=spectator sockets run; 0 = live
+			SpectatorHovers   bool `json:"spectator_hovers"`    // spectators see both teams' hovers (default: none)
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				http.Error(w, "bad json", http.StatusBadRequest)
+				return
+			}
+		}
+		format, ok := engine.LookupFormat(req.Format)
+		if !ok {
+			http.Error(w, "unknown draft format", http.StatusBadRequest)
+			return
+		}
+		fearless := engine.FearlessMode(req.Fearless)
+		switch fearless {
+		case "":
+			fearless = engine.FearlessOff
+		case engine.FearlessOff, engine.FearlessHard, engine.FearlessTeam:
+		default:
+			http.Error(w, "fearless must be off, hard or team", http.StatusBadRequest)
+			return
+		}
+		if req.TradeTimerSec < 0 {
+			http.Error(w, "trade_timer_sec must not be negative", http.StatusBadRequest)
+			return
+		}
+		if req.TimeBankSec < 0 {
+			http.Error(w, "time_bank_sec must not be negative", http.StatusBadRequest)
+			return
+		}
+		if req.SpectatorDelaySec < 0 {
+			http.Error(w, "spectator_delay_sec must not be negative", http.StatusBadRequest)
+			return
+		}
+		switch req.BestOf {
+		case 0:
+			req.BestOf = 1
+		case 1, 3, 5:
+		default:
+			http.Error(w, "best_of must be 1, 3 or 5", http.StatusBadRequest)
+			return
+		}
+
 		var code string
 		for {
 			c, err := GenerateCode()
@@ -35,27 +91,57 @@ func CreateLobby(h *hub.Hub) http.HandlerFunc {
 				http.Error(w, "failed to generate code", http.StatusInternalServerError)
 				return
 			}
-			reply := make(chan *lobby.Lobby, 1)
-			h.Inbox() <- hub.GetLobby{Code: c, Reply: reply}
-			if <-reply == nil {
+			// Stored lobbies count too, or the new one couldn't be persisted
+			taken, err := h.CodeTaken(r.Context(), c)
+			if err != nil {
+				http.Error(w, "failed to check lobby code", http.StatusInternalServerError)
+				return
+			}
+			if !taken {
 				code = c
 				break
 			}
 			fmt.Println("collision on code, regenerating")
 		}
 
+		state := engine.NewPreDraftState()
+		state.Rules.Format = format
+		state.Rules.Fearless = fearless
+		state.Rules.TradeTimerSec = req.TradeTimerSec
+		state.Rules.TimeBankSec = req.TimeBankSec
+		state.Rules.SpectatorDelaySec = req.SpectatorDelaySec
+		state.Rules.SpectatorHovers = req.SpectatorHovers
+		state.Series = engine.NewSeries(req.BestOf)
+		state.Phase = engine.DerivePhase(state)
+
 		reply := make(chan *lobby.Lobby, 1)
-		h.Inbox() <- hub.EnsureLobby{Code: code, State: engine.NewEmptyState(), Reply: reply}
-		if <-reply == nil {
-			http.Error(w, "failed to create lobby", http.StatusInternalServerError)
+		h.Inbox() <- hub.EnsureLobby{Code: code, State: state, Reply: reply}
+		lb := <-reply
+		if lb == nil {
+			// Only happens once the server is shutting down
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(struct {
-			Code string `json:"code"`
-		}{Code: code})
+			Code      string `json:"code"`
+			HostToken string `json:"host_token"` // send in a ClaimHost message to pause/resume
+		}{Code: code, HostToken: lb.HostToken()})
+	}
+}
+
+// ListChampions serves the roster the engine validates picks and bans against,
+// so the frontend never offers a champion the server would reject.
+func ListChampions(cat *catalog.Catalog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cat == nil {
+			http.Error(w, "champion catalog not loaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(cat.All())
 	}
 }
 
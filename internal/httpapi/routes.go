@@ -3,16 +3,18 @@ package httpapi
 import (
 	"net/http"
 
+	"github.com/DoyleJ11/lol-draft-backend/internal/catalog"
 	"github.com/DoyleJ11/lol-draft-backend/internal/hub"
 	"github.com/DoyleJ11/lol-draft-backend/internal/ws"
 	"github.com/go-chi/chi/v5"
 )
 
-func SetupRoutes(h *hub.Hub) http.Handler {
+func SetupRoutes(h *hub.Hub, cat *catalog.Catalog) http.Handler {
 	r := chi.NewRouter()
 
 	// Public routes
 	r.Post("/lobbies", CreateLobby(h))
+	r.Get("/champions", ListChampions(cat))
 	r.Get("/healthz", Healthz)
 	r.Get("/ws", ws.Handler(h))
 	return r
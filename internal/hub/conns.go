@@ -0,0 +1,52 @@
+package hub
+
+import (
+	"context"
+	"sync"
+)
+
+// conns counts the sockets open on the hub's lobbies. http.Server.Shutdown
+// doesn't wait for hijacked (WebSocket) connections, so the hub does. Once
+// it's shutting down no new ones are let in, which also keeps Add and Wait
+// from racing on the WaitGroup.
+type conns struct {
+	mu      sync.Mutex
+	closing bool
+	wg      sync.WaitGroup
+}
+
+// TrackConn registers a connection about to be opened; call done once it's
+// closed. ok is false once the hub is shutting down: refuse the connection.
+// Call it before hijacking, so WaitConns can't miss it.
+func (h *Hub) TrackConn() (done func(), ok bool) {
+	h.conns.mu.Lock()
+	defer h.conns.mu.Unlock()
+	if h.conns.closing {
+		return nil, false
+	}
+	h.conns.wg.Add(1)
+	return h.conns.wg.Done, true
+}
+
+// WaitConns stops taking connections and blocks until every tracked one has
+// closed, or ctx ends.
+func (h *Hub) WaitConns(ctx context.Context) error {
+	h.stopConns()
+	done := make(chan struct{})
+	go func() {
+		h.conns.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *Hub) stopConns() {
+	h.conns.mu.Lock()
+	h.conns.closing = true
+	h.conns.mu.Unlock()
+}
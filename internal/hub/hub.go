@@ -2,9 +2,14 @@ package hub
 
 import (
 	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
 	"github.com/DoyleJ11/lol-draft-backend/internal/lobby"
+	"github.com/DoyleJ11/lol-draft-backend/internal/store"
 )
 
 type HubMsg interface{ isHubMsg() }
@@ -33,11 +38,80 @@ type RemoveLobby struct {
 type Hub struct {
 	inbox   chan HubMsg
 	lobbies map[string]*lobby.Lobby
+	ages    map[string]*lobbyAge // GC bookkeeping, same keys as lobbies
+	store   store.Store          // nil = lobbies aren't persisted
+	gc      GCConfig
+
+	sendDeadline time.Duration // see WithBackpressure; zero = lobby default
+	maxMissed    int
+	champions    engine.Roster // nil = the engine's default
+
+	closing bool  // ShutdownHub received; no new lobbies
+	conns   conns // see TrackConn
 	ctx     context.Context
 	cancel  context.CancelFunc
 }
 
-type ShutdownHub struct{}
+// GCConfig sets when the hub shuts down lobbies nobody needs anymore. A zero
+// TTL turns that rule off.
+type GCConfig struct {
+	Idle      time.Duration // nobody connected for this long (once started; see collect)
+	Completed time.Duration // series decided this long ago
+	Unstarted time.Duration // created this long ago and still in pre-draft
+	Sweep     time.Duration // how often to check
+}
+
+var DefaultGC = GCConfig{
+	Idle:      15 * time.Minute,
+	Completed: 30 * time.Minute,
+	Unstarted: 2 * time.Hour,
+	Sweep:     30 * time.Second,
+}
+
+// Option configures a hub at construction.
+type Option func(*Hub)
+
+// WithStore persists every lobby the hub creates, and its events, to st.
+func WithStore(st store.Store) Option {
+	return func(h *Hub) { h.store = st }
+}
+
+// WithGC replaces DefaultGC.
+func WithGC(cfg GCConfig) Option {
+	return func(h *Hub) { h.gc = cfg }
+}
+
+// WithBackpressure sets how every lobby treats slow clients: how long a
+// pending update may wait before it counts as a missed deadline, and how many
+// misses in a row get the client disconnected (see lobby.WithBackpressure).
+// Zero keeps the lobby's default.
+func WithBackpressure(sendDeadline time.Duration, maxMissed int) Option {
+	return func(h *Hub) { h.sendDeadline, h.maxMissed = sendDeadline, maxMissed }
+}
+
+// WithChampions checks every lobby's picks and bans against r.
+func WithChampions(r engine.Roster) Option {
+	return func(h *Hub) { h.champions = r }
+}
+
+// ShutdownHub shuts every lobby down (clients are told the server is going
+// away) and stops taking new ones. With Reply set, the hub hands back the
+// lobbies it shut down and keeps running until the caller cancels it (see
+// Shutdown); without, it stops at once.
+type ShutdownHub struct {
+	Reply chan []*lobby.Lobby
+}
+
+// lobbyAge is what the GC sweep looks at for one lobby. The lobby writes its
+// latest status straight into it (see lobbyOptions) rather than messaging the
+// hub, so a busy hub never holds a lobby up, or the other way around.
+type lobbyAge struct {
+	mu         sync.Mutex
+	status     lobby.Status
+	created    time.Time
+	emptySince time.Time // zero while someone is connected
+	doneSince  time.Time // zero until the series is decided
+}
 
 func (CreateLobby) isHubMsg() {}
 func (GetLobby) isHubMsg()    {}
@@ -45,62 +119,283 @@ func (EnsureLobby) isHubMsg() {}
 func (RemoveLobby) isHubMsg() {}
 func (ShutdownHub) isHubMsg() {}
 
-func NewHub(parent context.Context) *Hub {
+func NewHub(parent context.Context, opts ...Option) *Hub {
 	ctx, cancel := context.WithCancel(parent)
 	h := &Hub{
 		inbox:   make(chan HubMsg, 64),
 		lobbies: make(map[string]*lobby.Lobby),
+		ages:    make(map[string]*lobbyAge),
+		gc:      DefaultGC,
 		ctx:     ctx,
 		cancel:  cancel,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.store != nil {
+		h.restore()
+	}
 	go h.loop()
 	return h
 }
 
 func (h *Hub) Inbox() chan<- HubMsg { return h.inbox }
 
+// Shutdown stops the hub taking new lobbies and sockets, shuts every lobby down and waits
+// for their goroutines to exit, or for ctx to end. The hub is stopped either way.
+// A lobby's goroutine only exits once its queued store writes are done.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	defer h.cancel()
+	h.stopConns()
+	reply := make(chan []*lobby.Lobby, 1)
+	select {
+	case h.inbox <- ShutdownHub{Reply: reply}:
+	case <-h.ctx.Done():
+		return nil // already stopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	var closed []*lobby.Lobby
+	select {
+	case closed = <-reply:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	for _, lb := range closed {
+		select {
+		case <-lb.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	log.Printf("hub: shut down %d lobbies", len(closed))
+	return nil
+}
+
+// CodeTaken reports whether code belongs to a running lobby or, with a store,
+// to any lobby ever stored under it (closed ones too). Safe to call from any
+// goroutine.
+func (h *Hub) CodeTaken(ctx context.Context, code string) (bool, error) {
+	reply := make(chan *lobby.Lobby, 1)
+	select {
+	case h.inbox <- GetLobby{Code: code, Reply: reply}:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	select {
+	case lb := <-reply:
+		if lb != nil {
+			return true, nil
+		}
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	if h.store == nil {
+		return false, nil
+	}
+	_, err := h.store.GetLobby(ctx, code)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, store.ErrNotFound):
+		return false, nil
+	}
+	return false, err
+}
+
 func (h *Hub) loop() {
+	var sweep <-chan time.Time
+	if h.gc.Sweep > 0 && (h.gc.Idle > 0 || h.gc.Completed > 0 || h.gc.Unstarted > 0) {
+		t := time.NewTicker(h.gc.Sweep)
+		defer t.Stop()
+		sweep = t.C
+	}
+
 	for {
 		select {
 		case <-h.ctx.Done():
 			// h.shutdown() <- Write helper function to shutdown hub
 			return
 
+		case now := <-sweep:
+			h.collect(now)
+
 		case m := <-h.inbox:
 			switch msg := m.(type) {
 			case CreateLobby:
+				if h.closing {
+					msg.Reply <- nil
+					break
+				}
 				if lb := h.lobbies[msg.Code]; lb != nil {
 					msg.Reply <- lb
 					break
 				}
-				lb := lobby.NewLobby(h.ctx, msg.State)
-				h.lobbies[msg.Code] = lb
+				lb := h.newLobby(msg.Code, msg.State)
 				msg.Reply <- lb
 
 			case GetLobby:
 				msg.Reply <- h.lobbies[msg.Code] // May be nil
 
 			case EnsureLobby:
+				if h.closing {
+					msg.Reply <- nil
+					break
+				}
 				if lb := h.lobbies[msg.Code]; lb != nil {
 					msg.Reply <- lb
 					break
 				}
 
-				lb := lobby.NewLobby(h.ctx, msg.State)
-				h.lobbies[msg.Code] = lb
+				lb := h.newLobby(msg.Code, msg.State)
 				msg.Reply <- lb
 
 			case RemoveLobby:
-				delete(h.lobbies, msg.Code)
+				h.remove(msg.Code, "removed")
 
 			case ShutdownHub:
+				h.closing = true
+				closed := make([]*lobby.Lobby, 0, len(h.lobbies))
 				for _, lb := range h.lobbies {
-					lb.Inbox() <- lobby.Shutdown{}
+					stop(lb, lobby.Shutdown{ServerShutdown: true})
+					closed = append(closed, lb)
 				}
 				clear(h.lobbies)
-				h.cancel()
+				clear(h.ages)
+				if msg.Reply == nil {
+					h.cancel()
+					break
+				}
+				// Keep serving (and refusing) until Shutdown is done waiting
+				msg.Reply <- closed
 			}
 
 		}
 	}
 }
+
+// newLobby starts a lobby and registers it with the hub.
+func (h *Hub) newLobby(code string, state engine.State) *lobby.Lobby {
+	lb := lobby.NewLobby(h.ctx, state, h.lobbyOptions(code)...)
+	h.lobbies[code] = lb
+	return lb
+}
+
+// lobbyOptions wires a lobby under code to the hub's store, GC, champion
+// roster and backpressure settings.
+func (h *Hub) lobbyOptions(code string) []lobby.Option {
+	age := &lobbyAge{created: time.Now()}
+	h.ages[code] = age
+	opts := []lobby.Option{lobby.WithStatusNotify(func(st lobby.Status) {
+		age.update(st, time.Now())
+	})}
+	if h.store != nil {
+		opts = append(opts, lobby.WithStore(h.store, code))
+	}
+	if h.champions != nil {
+		opts = append(opts, lobby.WithChampions(h.champions))
+	}
+	if h.sendDeadline > 0 || h.maxMissed > 0 {
+		opts = append(opts, lobby.WithBackpressure(h.sendDeadline, h.maxMissed))
+	}
+	return opts
+}
+
+func (a *lobbyAge) update(st lobby.Status, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case !st.Empty:
+		a.emptySince = time.Time{}
+	case a.emptySince.IsZero():
+		a.emptySince = now
+	}
+	if st.Done && a.doneSince.IsZero() {
+		a.doneSince = now
+	}
+	a.status = st
+}
+
+// collect shuts down every lobby past one of the GC TTLs. A lobby still in
+// pre-draft is left to the unstarted TTL rather than the idle one, so a
+// lobby made ahead of time isn't gone before anyone turns up.
+func (h *Hub) collect(now time.Time) {
+	expired := func(since time.Time, ttl time.Duration) bool {
+		return ttl > 0 && !since.IsZero() && now.Sub(since) >= ttl
+	}
+	for code, age := range h.ages {
+		age.mu.Lock()
+		why := ""
+		switch {
+		case age.status.Done && expired(age.doneSince, h.gc.Completed):
+			why = "series completed"
+		case !age.status.Started && expired(age.created, h.gc.Unstarted):
+			why = "never started"
+		case (age.status.Started || h.gc.Unstarted == 0) && expired(age.emptySince, h.gc.Idle):
+			why = "idle"
+		}
+		age.mu.Unlock()
+		if why != "" {
+			h.remove(code, why)
+		}
+	}
+}
+
+// remove shuts a lobby down and forgets it, in the store too, so a restart
+// doesn't bring it back.
+func (h *Hub) remove(code, why string) {
+	lb, ok := h.lobbies[code]
+	if !ok {
+		return
+	}
+	// The lobby's own writer marks it closed, after anything still queued
+	// (its creation included), and is done by the time the lobby's Done
+	stop(lb, lobby.Shutdown{Close: true})
+	delete(h.lobbies, code)
+	delete(h.ages, code)
+	log.Printf("hub: closed lobby %s (%s)", code, why)
+}
+
+// stop hands a lobby its Shutdown, unless it has already stopped by itself.
+func stop(lb *lobby.Lobby, msg lobby.Shutdown) {
+	select {
+	case lb.Inbox() <- msg:
+	case <-lb.Done():
+	}
+}
+
+// restore brings back every lobby the store says is still in progress, so
+// after a restart clients can reconnect to the same codes. A lobby that
+// can't be read back is logged and skipped, and so is one that never started
+// and is already past the unstarted TTL.
+func (h *Hub) restore() {
+	recs, err := h.store.ActiveLobbies(h.ctx)
+	if err != nil {
+		log.Printf("hub: listing lobbies to restore: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, rec := range recs {
+		if rec.Status == store.LobbyOpen && h.gc.Unstarted > 0 && now.Sub(rec.CreatedAt) >= h.gc.Unstarted {
+			log.Printf("hub: not restoring %s (never started)", rec.Code)
+			continue
+		}
+		events, err := h.store.Events(h.ctx, rec.Code)
+		if err != nil {
+			log.Printf("hub: restore %s: %v", rec.Code, err)
+			continue
+		}
+		opts := append(h.lobbyOptions(rec.Code), lobby.WithSecret(rec.Secret), lobby.WithVersion(rec.Version))
+		h.ages[rec.Code].created = rec.CreatedAt // the unstarted TTL runs from the original creation
+		lb, err := lobby.Restore(h.ctx, store.LogEntries(events), opts...)
+		if err != nil {
+			delete(h.ages, rec.Code)
+			log.Printf("hub: restore %s: %v", rec.Code, err)
+			continue
+		}
+		h.lobbies[rec.Code] = lb
+	}
+	if len(h.lobbies) > 0 {
+		log.Printf("hub: restored %d lobbies", len(h.lobbies))
+	}
+}
@@ -2,10 +2,14 @@ package hub
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
 	"github.com/DoyleJ11/lol-draft-backend/internal/lobby"
+	"github.com/DoyleJ11/lol-draft-backend/internal/store"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
 )
 
 func TestHub_Create_Get_SamePointer(t *testing.T) {
@@ -60,3 +64,309 @@ func TestHub_Create_Remove_CheckGetNil(t *testing.T) {
 		t.Fatalf("expected no lobby found")
 	}
 }
+
+func TestHub_WithStore_PersistsNewLobbies(t *testing.T) {
+	st := store.NewMemory()
+	h := NewHub(context.Background(), WithStore(st))
+	reply := make(chan *lobby.Lobby, 1)
+
+	h.Inbox() <- EnsureLobby{Code: "SAVED1", State: engine.NewPreDraftState(), Reply: reply}
+	lb := <-reply
+	if lb == nil {
+		t.Fatalf("expected a lobby")
+	}
+
+	// Written off the hub goroutine; they're in once the lobby has stopped
+	h.Inbox() <- ShutdownHub{}
+	<-lb.Done()
+	if _, err := st.GetLobby(context.Background(), "SAVED1"); err != nil {
+		t.Fatalf("lobby not persisted: %v", err)
+	}
+	events, err := st.Events(context.Background(), "SAVED1")
+	if err != nil || len(events) != 1 || events[0].Event.Type != engine.EvtLobbyCreated {
+		t.Fatalf("want the LobbyCreated event stored, got %+v (%v)", events, err)
+	}
+}
+
+func TestHub_CodeTaken_CountsStoredLobbies(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	h := NewHub(ctx, WithStore(st))
+	reply := make(chan *lobby.Lobby, 1)
+	h.Inbox() <- EnsureLobby{Code: "OLD001", State: engine.NewPreDraftState(), Reply: reply}
+	lb := <-reply
+	h.Inbox() <- RemoveLobby{Code: "OLD001"}
+	<-lb.Done()
+
+	// Gone from the hub but still in the store: a new lobby can't reuse it
+	if taken, err := h.CodeTaken(ctx, "OLD001"); err != nil || !taken {
+		t.Fatalf("want OLD001 taken, got %v (%v)", taken, err)
+	}
+	if taken, err := h.CodeTaken(ctx, "NEW001"); err != nil || taken {
+		t.Fatalf("want NEW001 free, got %v (%v)", taken, err)
+	}
+}
+
+func TestHub_WithStore_RestoresActiveLobbies(t *testing.T) {
+	st := store.NewMemory()
+	h := NewHub(context.Background(), WithStore(st))
+	reply := make(chan *lobby.Lobby, 1)
+
+	h.Inbox() <- CreateLobby{Code: "CRASH1", State: engine.NewEmptyState(), Reply: reply}
+	before := <-reply
+	before.Inbox() <- lobby.ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+	views := make(chan lobby.View, 1)
+	before.Inbox() <- lobby.GetState{Reply: views}
+	was := <-views
+	h.Inbox() <- ShutdownHub{}
+	<-before.Done() // its writes are in the store
+
+	// "Restart": a new hub on the same store
+	h = NewHub(context.Background(), WithStore(st))
+	h.Inbox() <- GetLobby{Code: "CRASH1", Reply: reply}
+	after := <-reply
+	if after == nil {
+		t.Fatalf("expected the lobby to be restored")
+	}
+	if after.HostToken() != before.HostToken() {
+		t.Fatalf("host token changed across the restart")
+	}
+	after.Inbox() <- lobby.GetState{Reply: views}
+	now := <-views
+	if now.State.Cursor != 1 || len(now.Log) != len(was.Log) {
+		t.Fatalf("want cursor 1 and %d events, got cursor=%d events=%d", len(was.Log), now.State.Cursor, len(now.Log))
+	}
+
+	out := make(chan types.ServerMessage, 4)
+	after.Inbox() <- lobby.Join{ClientID: "c1", Outbox: out}
+	if snap := <-out; snap.Timer == nil || snap.Timer.RemainingMs <= 0 {
+		t.Fatalf("want the ban clock still running, got %+v", snap.Timer)
+	}
+	h.Inbox() <- ShutdownHub{}
+}
+
+func TestHub_GC_CollectedLobbiesStayGoneAfterRestart(t *testing.T) {
+	st := store.NewMemory()
+	gc := GCConfig{Idle: 20 * time.Millisecond, Sweep: 5 * time.Millisecond}
+	h := NewHub(context.Background(), WithStore(st), WithGC(gc))
+	reply := make(chan *lobby.Lobby, 1)
+	h.Inbox() <- CreateLobby{Code: "IDLE01", State: engine.NewEmptyState(), Reply: reply}
+	lb := <-reply
+
+	select {
+	case <-lb.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("want the idle lobby collected")
+	}
+	h.Inbox() <- ShutdownHub{}
+
+	h = NewHub(context.Background(), WithStore(st), WithGC(gc))
+	h.Inbox() <- GetLobby{Code: "IDLE01", Reply: reply}
+	if <-reply != nil {
+		t.Fatalf("collected lobby came back after a restart")
+	}
+	h.Inbox() <- ShutdownHub{}
+}
+
+func TestHub_Restore_SkipsStaleUnstartedLobbies(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	initial := engine.NewPreDraftState()
+	for code, age := range map[string]time.Duration{"STALE1": 3 * time.Hour, "FRESH1": time.Minute} {
+		rec := store.LobbyFromState(code, initial)
+		rec.CreatedAt = time.Now().Add(-age)
+		if err := st.CreateLobby(ctx, rec); err != nil {
+			t.Fatalf("create %s: %v", code, err)
+		}
+		entry := store.Event{LogEntry: engine.LogEntry{Ordinal: 1, Batch: 1, At: rec.CreatedAt, Event: engine.Event{Type: engine.EvtLobbyCreated, Initial: &initial}}}
+		if err := st.AppendEvents(ctx, code, []store.Event{entry}); err != nil {
+			t.Fatalf("append %s: %v", code, err)
+		}
+	}
+
+	h := NewHub(ctx, WithStore(st)) // DefaultGC: unstarted lobbies go after 2h
+	reply := make(chan *lobby.Lobby, 1)
+	h.Inbox() <- GetLobby{Code: "STALE1", Reply: reply}
+	if <-reply != nil {
+		t.Fatalf("want the 3h-old unstarted lobby left behind")
+	}
+	h.Inbox() <- GetLobby{Code: "FRESH1", Reply: reply}
+	if <-reply == nil {
+		t.Fatalf("want the fresh lobby restored")
+	}
+	h.Inbox() <- ShutdownHub{}
+}
+
+func TestHub_GC_ClosesIdleAndUnstartedLobbies(t *testing.T) {
+	h := NewHub(context.Background(), WithGC(GCConfig{Idle: 50 * time.Millisecond, Unstarted: 150 * time.Millisecond, Sweep: 10 * time.Millisecond}))
+	reply := make(chan *lobby.Lobby, 1)
+	get := func(code string) *lobby.Lobby {
+		h.Inbox() <- GetLobby{Code: code, Reply: reply}
+		return <-reply
+	}
+
+	// Nobody ever joins this one
+	h.Inbox() <- CreateLobby{Code: "EMPTY1", State: engine.NewEmptyState(), Reply: reply}
+	<-reply
+
+	// Someone sits in this one, but it never leaves pre-draft
+	h.Inbox() <- CreateLobby{Code: "STUCK1", State: engine.NewPreDraftState(), Reply: reply}
+	out := make(chan types.ServerMessage, 8)
+	(<-reply).Inbox() <- lobby.Join{ClientID: "c1", Outbox: out}
+
+	time.Sleep(100 * time.Millisecond)
+	if get("EMPTY1") != nil {
+		t.Fatalf("want the empty lobby collected")
+	}
+	if get("STUCK1") == nil {
+		t.Fatalf("occupied lobby collected before its unstarted TTL")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if get("STUCK1") != nil {
+		t.Fatalf("want the never-started lobby collected")
+	}
+	for range out {
+		// drained until Shutdown closes it
+	}
+	h.Inbox() <- ShutdownHub{}
+}
+
+func TestHub_GC_UnstartedLobbyOutlivesIdleTTL(t *testing.T) {
+	h := NewHub(context.Background(), WithGC(GCConfig{Idle: 20 * time.Millisecond, Unstarted: 150 * time.Millisecond, Sweep: 5 * time.Millisecond}))
+	reply := make(chan *lobby.Lobby, 1)
+
+	// Made ahead of time; nobody has joined yet
+	h.Inbox() <- CreateLobby{Code: "EARLY1", State: engine.NewPreDraftState(), Reply: reply}
+	lb := <-reply
+
+	select {
+	case <-lb.Done():
+		t.Fatalf("unstarted lobby collected on the idle TTL")
+	case <-time.After(80 * time.Millisecond):
+	}
+	select {
+	case <-lb.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("want the lobby collected on the unstarted TTL")
+	}
+	h.Inbox() <- ShutdownHub{}
+}
+
+func TestHub_Shutdown_WaitsForLobbiesAndRefusesNewOnes(t *testing.T) {
+	h := NewHub(context.Background())
+	reply := make(chan *lobby.Lobby, 1)
+	h.Inbox() <- CreateLobby{Code: "BYE123", State: engine.NewPreDraftState(), Reply: reply}
+	lb := <-reply
+
+	// Keep the hub loop alive past ShutdownHub to check it refuses creates
+	hubReply := make(chan []*lobby.Lobby, 1)
+	h.Inbox() <- ShutdownHub{Reply: hubReply}
+	if closed := <-hubReply; len(closed) != 1 || closed[0] != lb {
+		t.Fatalf("want the one lobby handed back, got %v", closed)
+	}
+	h.Inbox() <- EnsureLobby{Code: "NEW123", State: engine.NewPreDraftState(), Reply: reply}
+	if <-reply != nil {
+		t.Fatalf("want no new lobbies while shutting down")
+	}
+
+	select {
+	case <-lb.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("lobby still running after ShutdownHub")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestHub_Shutdown_WaitsForLobbyGoroutines(t *testing.T) {
+	h := NewHub(context.Background())
+	reply := make(chan *lobby.Lobby, 1)
+	h.Inbox() <- CreateLobby{Code: "BYE456", State: engine.NewPreDraftState(), Reply: reply}
+	lb := <-reply
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	select {
+	case <-lb.Done():
+	default:
+		t.Fatalf("lobby still running after Shutdown")
+	}
+}
+
+func TestHub_Shutdown_GivesUpOnAStuckHub(t *testing.T) {
+	// No loop running: nothing will ever take ShutdownHub
+	hubCtx, hubCancel := context.WithCancel(context.Background())
+	h := &Hub{inbox: make(chan HubMsg), ctx: hubCtx, cancel: hubCancel}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := h.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want the deadline, got %v", err)
+	}
+}
+
+func TestHub_WaitConns_WaitsForTrackedAndRefusesNew(t *testing.T) {
+	h := NewHub(context.Background())
+	done, ok := h.TrackConn()
+	if !ok {
+		t.Fatalf("want the connection let in")
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- h.WaitConns(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := h.TrackConn(); ok {
+		t.Fatalf("want new connections refused once waiting")
+	}
+	select {
+	case <-waited:
+		t.Fatalf("WaitConns returned with a connection still open")
+	default:
+	}
+
+	done()
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("WaitConns still waiting after the last connection closed")
+	}
+	h.Inbox() <- ShutdownHub{}
+}
+
+func TestHub_WithBackpressure_ReachesLobbies(t *testing.T) {
+	h := NewHub(context.Background(), WithBackpressure(10*time.Millisecond, 2))
+	reply := make(chan *lobby.Lobby, 1)
+	state := engine.NewEmptyState()
+	state.Cursor = 6
+	h.Inbox() <- CreateLobby{Code: "SLOW01", State: state, Reply: reply}
+	lb := <-reply
+
+	// Never read: the welcome fills it and the pick has to wait
+	out := make(chan types.ServerMessage, 1)
+	lb.Inbox() <- lobby.Join{ClientID: "c1", Outbox: out}
+	lb.Inbox() <- lobby.ServerCommand{Cmd: engine.Command{Type: engine.CmdLockPick, Team: engine.TeamBlue, ChampionID: 266}}
+
+	// The lobby default (5 misses of 2s) would keep it around for 10s
+	deadline := time.Now().Add(time.Second)
+	for {
+		views := make(chan lobby.View, 1)
+		lb.Inbox() <- lobby.GetState{Reply: views}
+		if v := <-views; v.NumClients == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("slow client still connected after its deadlines passed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,168 @@
+package lobby
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+var ErrLagging = errors.New("too far behind; reconnect with your session to pick back up")
+
+// Backpressure: a client whose outbox is full isn't dropped. Its state
+// updates coalesce into a single pending one (the latest full snapshot, sent
+// as soon as there's room), and each sendDeadline that passes without it
+// getting through counts as a miss. After maxMissed misses in a row it gets
+// a "Lagging" notice and is disconnected; a seated client still has its
+// reconnect grace.
+//
+// Anything else (errors, SeatClaimed with its token...) can't be rebuilt from
+// a snapshot, so it queues instead and goes out in order, ahead of the
+// pending snapshot. Nothing overtakes a queued message. A client that lets
+// more than maxQueued pile up is out of misses: the next flush disconnects it
+// unless it has caught up by then.
+
+const maxQueued = 32
+
+// flushPending retries pending snapshots; sent while anyone is behind.
+type flushPending struct{}
+
+func (flushPending) isLobbyMsg() {}
+
+// WithBackpressure sets how long a slow client's pending snapshot may wait
+// before that counts as a missed deadline, and how many misses in a row get
+// it disconnected. Default 2s and 5; a zero leaves that one at its default.
+func WithBackpressure(sendDeadline time.Duration, maxMissed int) Option {
+	return func(l *Lobby) {
+		if sendDeadline > 0 {
+			l.sendDeadline = sendDeadline
+		}
+		if maxMissed > 0 {
+			l.maxMissed = maxMissed
+		}
+	}
+}
+
+func isStateMsg(m types.ServerMessage) bool {
+	return m.Type == "StateSnapshot" || m.Type == "StatePatch"
+}
+
+// behind marks c as owing a fresh snapshot.
+func (l *Lobby) behind(clientID string, c *client) {
+	if !c.behindSince.IsZero() {
+		return // already coalescing; the pending snapshot will be the latest
+	}
+	now := time.Now()
+	c.behindSince, c.sendBy, c.missed = now, now.Add(l.sendDeadline), 0
+	log.Printf("client=%s outbox full; coalescing state updates", clientID)
+	l.armFlush()
+}
+
+// queue holds a message that isn't a state update until c has room for it.
+// Evicting is left to flush, as sendTo may be partway through a broadcast.
+func (l *Lobby) queue(clientID string, c *client, m types.ServerMessage) {
+	c.queued = append(c.queued, m)
+	l.behind(clientID, c)
+	if len(c.queued) > maxQueued {
+		c.missed, c.sendBy = l.maxMissed, time.Now()
+	}
+}
+
+// sendQueued sends as much of c's queue as fits and reports whether it all
+// went.
+func (c *client) sendQueued() bool {
+	for len(c.queued) > 0 {
+		select {
+		case c.out <- c.queued[0]:
+			c.queued = c.queued[1:]
+		default:
+			return false
+		}
+	}
+	c.queued = nil
+	return true
+}
+
+// caughtUp records how long c was behind once its snapshot got through.
+func (c *client) caughtUp() {
+	if c.behindSince.IsZero() {
+		c.lag = 0
+		return
+	}
+	c.lag = time.Since(c.behindSince)
+	c.behindSince, c.missed = time.Time{}, 0
+}
+
+// lagNow is how far behind c is: how long its pending snapshot has waited,
+// or how long the last one did.
+func (c *client) lagNow() time.Duration {
+	if !c.behindSince.IsZero() {
+		return time.Since(c.behindSince)
+	}
+	return c.lag
+}
+
+func (l *Lobby) armFlush() {
+	if l.flushTimer != nil {
+		return
+	}
+	l.flushTimer = time.AfterFunc(max(l.sendDeadline/4, time.Millisecond), func() {
+		select {
+		case l.inbox <- flushPending{}:
+		case <-l.ctx.Done():
+		}
+	})
+}
+
+// flush retries every queued message and pending snapshot, and counts misses
+// for those that still don't fit.
+func (l *Lobby) flush() {
+	l.flushTimer = nil
+	now := time.Now()
+	pending := false
+	for id, c := range l.clients {
+		if c.behindSince.IsZero() || c.out == nil {
+			continue
+		}
+		if c.sendQueued() {
+			msg := l.snapshotFor(c)
+			msg.Reason = "Coalesced"
+			select {
+			case c.out <- msg:
+				c.caughtUp()
+				log.Printf("client=%s caught up after %s", id, c.lag)
+				continue
+			default:
+			}
+		}
+		if now.After(c.sendBy) {
+			c.missed++
+			c.sendBy = now.Add(l.sendDeadline)
+			if c.missed >= l.maxMissed {
+				l.evict(id, c)
+				continue
+			}
+		}
+		pending = true
+	}
+	if pending {
+		l.armFlush()
+	}
+}
+
+// evict disconnects a client that kept missing deadlines, telling it why.
+func (l *Lobby) evict(clientID string, c *client) {
+	log.Printf("client=%s evicted: %d missed deadlines, %s behind", clientID, c.missed, c.lagNow())
+	// Make room for the notice; whatever it displaces was stale anyway
+	select {
+	case <-c.out:
+	default:
+	}
+	select {
+	case c.out <- types.ServerMessage{Type: "Lagging", Error: ErrLagging.Error()}:
+	default:
+	}
+	c.behindSince, c.missed, c.queued = time.Time{}, 0, nil
+	l.disconnect(clientID)
+}
@@ -0,0 +1,50 @@
+package lobby
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+var ErrNotHost = errors.New("only the host can do that")
+var ErrBadHostToken = errors.New("invalid host token")
+
+// ClaimHost marks a connection as the lobby's host, which lets it pause and
+// resume the draft. The token comes back from POST /lobbies.
+type ClaimHost struct {
+	ClientID string
+	Token    string
+}
+
+func (ClaimHost) isLobbyMsg() {}
+
+// HostToken is the secret the lobby's creator presents in ClaimHost. Safe to
+// call from any goroutine; the secret never changes after NewLobby.
+func (l *Lobby) HostToken() string {
+	return base64.RawURLEncoding.EncodeToString(l.mac("host"))
+}
+
+func (l *Lobby) claimHost(msg ClaimHost) {
+	c, ok := l.clients[msg.ClientID]
+	if !ok {
+		return
+	}
+	if c.spectator {
+		l.sendTo(msg.ClientID, types.ServerMessage{Type: "Unauthorized", Error: ErrSpectator.Error()})
+		return
+	}
+	if !hmac.Equal([]byte(msg.Token), []byte(l.HostToken())) {
+		l.sendTo(msg.ClientID, types.ServerMessage{Type: "Unauthorized", Error: ErrBadHostToken.Error()})
+		return
+	}
+	c.host = true
+	l.sendTo(msg.ClientID, types.ServerMessage{Type: "HostClaimed"})
+}
+
+// isHost reports whether clientID may use host controls.
+func (l *Lobby) isHost(clientID string) bool {
+	c, ok := l.clients[clientID]
+	return ok && c.host
+}
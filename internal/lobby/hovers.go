@@ -0,0 +1,71 @@
+package lobby
+
+import (
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+// Hovers are team-private. A seated client sees its own team's hovers and
+// never the other side's; the champion only shows up for them once it's
+// locked (which clears the hover). Spectators see every hover or none, per
+// Rules.SpectatorHovers, and connections without a seat see none.
+
+// visibleTo cuts a snapshot's hovers down to what c may see.
+func (l *Lobby) visibleTo(c *client, msg types.ServerMessage) types.ServerMessage {
+	if c.spectator {
+		return l.hoversForSpectators(msg)
+	}
+	return withHovers(msg, func(seatID string) bool {
+		return c.team != "" && l.seatTeam(seatID) == c.team
+	})
+}
+
+// audience groups clients that see the same hovers.
+func (l *Lobby) audience(c *client) string {
+	if c.spectator {
+		return "spectators"
+	}
+	return string(c.team) // "" = no seat
+}
+
+func (l *Lobby) hoversForSpectators(msg types.ServerMessage) types.ServerMessage {
+	if l.state.Rules.SpectatorHovers {
+		return msg
+	}
+	return withHovers(msg, func(string) bool { return false })
+}
+
+// withHovers copies msg with only the hovers keep accepts. The rest of the
+// state is shared, not copied: snapshots are never written to once built.
+func withHovers(msg types.ServerMessage, keep func(seatID string) bool) types.ServerMessage {
+	if msg.State == nil || len(msg.State.Hover) == 0 {
+		return msg
+	}
+	state := *msg.State
+	state.Hover = make(map[string]int, len(msg.State.Hover))
+	for seatID, champ := range msg.State.Hover {
+		if keep(seatID) {
+			state.Hover[seatID] = champ
+		}
+	}
+	msg.State = &state
+	return msg
+}
+
+// seatTeam is the side a hovering seat is on: from the roster, or failing
+// that (a draft started without pre-draft) from whoever claimed the seat.
+func (l *Lobby) seatTeam(seatID string) engine.Team {
+	for team, seats := range l.state.Seats {
+		for _, seat := range seats {
+			if seat.ID == seatID {
+				return team
+			}
+		}
+	}
+	for _, c := range l.clients {
+		if c.seatID == seatID {
+			return c.team
+		}
+	}
+	return ""
+}
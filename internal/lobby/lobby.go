@@ -3,9 +3,12 @@ package lobby
 import (
 	"context"
 	"log"
+	"math/rand/v2"
+	"slices"
 	"time"
 
 	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/store"
 	"github.com/DoyleJ11/lol-draft-backend/internal/types"
 )
 
@@ -27,14 +30,30 @@ type FromClient struct {
 
 func (FromClient) isLobbyMsg() {}
 
+// ServerCommand runs Cmd on the server's own authority, with no seat or host
+// checks. For server-side tooling and tests; connections always come in
+// through FromClient. Errors are only logged.
+type ServerCommand struct {
+	Cmd engine.Command
+}
+
+func (ServerCommand) isLobbyMsg() {}
+
 type Join struct {
-	ClientID string
-	Outbox   chan types.ServerMessage // changed: envelope channel
+	ClientID  string
+	Outbox    chan types.ServerMessage // changed: envelope channel
+	Spectator bool                     // watch only, on the spectator delay
+	Patches   bool                     // StatePatch after the first snapshot
 }
 
 func (Join) isLobbyMsg() {}
 
-type Leave struct{ ClientID string }
+// Leave is sent when a socket closes. Outbox identifies which socket, so a
+// late Leave from a socket that's already been replaced by a Resume is ignored.
+type Leave struct {
+	ClientID string
+	Outbox   chan types.ServerMessage
+}
 
 func (Leave) isLobbyMsg() {}
 
@@ -42,7 +61,14 @@ type TimerFired struct{ Gen int }
 
 func (TimerFired) isLobbyMsg() {}
 
-type Shutdown struct{}
+// Shutdown stops the lobby and closes every client's outbox. With
+// ServerShutdown set, clients first get a "ServerShuttingDown" message
+// carrying the final state. With Close set the lobby is also marked closed in
+// its store (after its queued writes), so a restart leaves it be.
+type Shutdown struct {
+	ServerShutdown bool
+	Close          bool
+}
 
 func (Shutdown) isLobbyMsg() {}
 
@@ -51,6 +77,9 @@ type GetState struct{ Reply chan View }
 
 func (GetState) isLobbyMsg() {}
 
+// PrimeTimer arms the turn clock as if the turn had just started. A 0s turn
+// has no clock and commands never arm one; priming it times the turn out
+// right away instead, so tests can drive a timeout without waiting.
 type PrimeTimer struct{}
 
 func (PrimeTimer) isLobbyMsg() {}
@@ -66,39 +95,168 @@ type View struct {
 	Version    int
 	NumClients int
 	State      engine.State
+	Log        []engine.LogEntry
+	Lag        map[string]time.Duration // per client; see (*client).lagNow
+}
+
+// client is one connection. team/seatID are set once it claims a seat; until
+// then it can watch but not send draft commands.
+type client struct {
+	out     chan types.ServerMessage // nil while disconnected
+	team    engine.Team
+	seatID  string
+	session string
+	host    bool // presented the host token; may pause/resume
+
+	spectator bool // watch only; gets snapshots on the spectator delay
+	patches   bool // gets StatePatch instead of StateSnapshot after joining
+
+	graceGen   int // bumped on every disconnect so stale GraceExpired fires are dropped
+	graceTimer *time.Timer
+
+	// Backpressure: set while a state update is owed (outbox was full)
+	behindSince time.Time
+	sendBy      time.Time // next deadline for the pending snapshot
+	missed      int       // deadlines missed in a row
+	lag         time.Duration
+	queued      []types.ServerMessage // non-state messages waiting for room, oldest first
 }
 
 type Lobby struct {
-	inbox     chan Msg
-	state     engine.State
-	version   int
-	clients   map[string]chan types.ServerMessage
-	turnTimer *time.Timer
-	timerGen  int
-	ctx       context.Context
-	cancel    context.CancelFunc
+	inbox    chan Msg
+	state    engine.State
+	version  int
+	clients  map[string]*client
+	secret   []byte            // signs seat tokens
+	sessions map[string]string // session token -> client ID
+	grace    time.Duration     // how long a seated client may be gone before the seat is vacated
+
+	sendDeadline time.Duration // see WithBackpressure
+	maxMissed    int
+	flushTimer   *time.Timer
+	turnTimer    *time.Timer
+	timerGen     int
+	deadline     time.Time // when the armed turn timer fires; zero when stopped
+	bankStart    time.Time // when the team on turn started draining its timebank
+
+	// Every event the lobby has folded into state, starting with
+	// EvtLobbyCreated; state is always Reduce(log)
+	log   []engine.LogEntry
+	batch int
+	redo  []engine.Command // commands undone since the last new action
+
+	results map[engine.Team]resultReport // captains' StartNextGame reports; see results.go
+
+	history []types.ServerMessage // recent broadcasts, newest last; patches and Syncs are taken against these
+
+	spectatorQueue []delayed           // snapshots not yet shown to spectators
+	spectatorView  types.ServerMessage // the latest one they have been shown
+	spectatorTimer *time.Timer
+
+	store     store.Store // nil = memory only
+	writer    *writer     // set with store; see persist.go
+	code      string
+	champions engine.Roster // see WithChampions
+	notify    func(Status)  // see WithStatusNotify
+
+	reported   bool
+	lastStatus Status
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the loop has exited
 }
 
-func NewLobby(parent context.Context, initial engine.State) *Lobby {
-	ctx, cancel := context.WithCancel(parent)
+// Option configures a lobby at construction.
+type Option func(*Lobby)
+
+// WithStore persists the lobby (under code) and every event it logs, off the
+// lobby goroutine. Store errors are logged, not fatal: the draft carries on in
+// memory.
+func WithStore(st store.Store, code string) Option {
+	return func(l *Lobby) { l.store, l.code = st, code }
+}
+
+// WithSecret sets the key seat and host tokens are signed with, so tokens
+// handed out before a restart still work after it. Default is random.
+func WithSecret(secret []byte) Option {
+	return func(l *Lobby) {
+		if len(secret) > 0 {
+			l.secret = secret
+		}
+	}
+}
+
+// WithVersion sets the state version a restored lobby carries on above: the
+// last one its clients were sent before the restart (store.Lobby.Version).
+func WithVersion(v int) Option {
+	return func(l *Lobby) { l.version = v }
+}
+
+// WithChampions sets the roster picks and bans are checked against (it goes
+// on the draft's Rules). Rosters aren't stored, so pass it again to Restore.
+func WithChampions(r engine.Roster) Option {
+	return func(l *Lobby) { l.champions = r }
+}
+
+// WithReconnectGrace sets how long a dropped seated client is held as
+// "reconnecting" before the seat shows vacant. Default 30s.
+func WithReconnectGrace(d time.Duration) Option {
+	return func(l *Lobby) { l.grace = d }
+}
 
+func NewLobby(parent context.Context, initial engine.State, opts ...Option) *Lobby {
 	// Optional (nice): make the very first snapshot show a real phase
-	initial.Phase = engine.DerivePhase(initial.Cursor)
+	initial.Phase = engine.DerivePhase(initial)
 
-	l := &Lobby{
-		inbox:   make(chan Msg, 64),
-		state:   initial,
-		version: 0,
-		clients: make(map[string]chan types.ServerMessage),
-		ctx:     ctx,
-		cancel:  cancel,
+	// Every lobby gets its own seed so timeout auto-picks differ between
+	// lobbies but replay identically from the event log.
+	if initial.Seed == 0 {
+		initial.Seed = rand.Int64()
 	}
+
+	l := newLobby(parent, opts)
+	if l.champions != nil {
+		initial.Rules.Champions = l.champions
+	}
+	genesis := initial.Clone()
+	l.record([]engine.Event{{Type: engine.EvtLobbyCreated, Initial: &genesis}})
+	if l.store != nil {
+		l.create(initial)
+	}
+	l.remember(l.snapshot(""))
+	l.spectatorView = l.hoversForSpectators(l.history[0])
 	go l.loop()
 	return l
 }
 
+func newLobby(parent context.Context, opts []Option) *Lobby {
+	ctx, cancel := context.WithCancel(parent)
+	l := &Lobby{
+		inbox:    make(chan Msg, 64),
+		version:  0,
+		clients:  make(map[string]*client),
+		secret:   newSecret(),
+		sessions: make(map[string]string),
+		results:  make(map[engine.Team]resultReport),
+		grace:    30 * time.Second,
+
+		sendDeadline: 2 * time.Second,
+		maxMissed:    5,
+		ctx:          ctx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
 func (l *Lobby) loop() {
+	defer close(l.done)
 	for {
+		l.reportStatus()
 		select {
 		case <-l.ctx.Done():
 			l.shutdown()
@@ -109,57 +267,54 @@ func (l *Lobby) loop() {
 
 			case Join:
 				// Register client and immediately send current snapshot
-				l.clients[msg.ClientID] = msg.Outbox
-				l.sendTo(msg.ClientID, types.ServerMessage{
-					Type:    "StateSnapshot",
-					Version: l.version,
-					State:   &l.state,
-				})
+				l.join(msg.ClientID, msg.Outbox, joinAs{spectator: msg.Spectator, patches: msg.Patches}, false)
+
+			case Resume:
+				l.resume(msg)
 
 			case Leave:
-				if ch, ok := l.clients[msg.ClientID]; ok {
-					close(ch) // let WS writer goroutine exit
-					delete(l.clients, msg.ClientID)
+				if c, ok := l.clients[msg.ClientID]; ok && (msg.Outbox == nil || msg.Outbox == c.out) {
+					l.disconnect(msg.ClientID)
 				}
 
+			case GraceExpired:
+				l.expireGrace(msg)
+
 			case FromClient:
-				log.Printf("FromClient: cursor=%d cmd=%s", l.state.Cursor, msg.Cmd.Type)
-				events, newState, err := engine.Apply(l.state, msg.Cmd)
-				if err != nil {
-					log.Printf("ApplyError: client=%s err=%v", msg.ClientID, err)
-					// Send error ONLY to this client; don't broadcast
+				cmd := msg.Cmd
+				if err := l.authorize(msg.ClientID, &cmd); err != nil {
+					log.Printf("Unauthorized: client=%s cmd=%s err=%v", msg.ClientID, cmd.Type, err)
 					l.sendTo(msg.ClientID, types.ServerMessage{
-						Type:  "Error",
+						Type:  "Unauthorized",
 						Error: err.Error(),
 					})
 					break
 				}
-
-				// Success path: update state/cursor/phase, version++, broadcast snapshot
-				l.state = newState
-				for _, e := range events {
-					switch e.Type {
-					case engine.EvtTurnAdvanced:
-						l.state.Cursor++
-					case engine.EvtGameCompleted:
-						l.stopTurnTimer()
-					case engine.EvtChampionPicked, engine.EvtChampionBanned:
-						// Clear any hovers that now point to a taken/banned champ
-						for seat, champ := range l.state.Hover {
-							if champ == e.ChampionID {
-								delete(l.state.Hover, seat)
-							}
-						}
-					}
+				if cmd.Type == engine.CmdStartNextGame && !l.reportResult(msg.ClientID, cmd) {
+					break // waiting on the other captain
 				}
-				l.state.Phase = engine.DerivePhase(l.state.Cursor)
-				l.version++
-				l.broadcastState()
+				_ = l.handleCommand(msg.ClientID, cmd)
 
-				// (Re)arm timer if turn advanced and game not completed
-				if hasEvent(events, engine.EvtTurnAdvanced) && !hasEvent(events, engine.EvtGameCompleted) {
-					l.armTurnTimer()
-				}
+			case ServerCommand:
+				_ = l.handleCommand("", msg.Cmd)
+
+			case ClaimSeat:
+				l.claimSeat(msg)
+
+			case RequestSnapshot:
+				l.sendSnapshot(msg.ClientID)
+
+			case Sync:
+				l.sync(msg)
+
+			case ClaimHost:
+				l.claimHost(msg)
+
+			case UndoLastAction:
+				l.undo(msg.ClientID)
+
+			case RedoAction:
+				l.redoAction(msg.ClientID)
 
 			case TimerFired:
 				log.Printf("timer: fired gen=%d (current=%d) cursor=%d", msg.Gen, l.timerGen, l.state.Cursor)
@@ -167,40 +322,41 @@ func (l *Lobby) loop() {
 					// stale fire — ignore
 					break
 				}
-				cmd := engine.Command{Type: engine.CmdTimeoutAdvance, SeatID: ""} // TODO: seat auth later
-				events, newState, err := engine.Apply(l.state, cmd)
-				if err != nil {
-					// should not really happen; ignore in v1
-					break
-				}
-				l.state = newState
-				for _, e := range events {
-					switch e.Type {
-					case engine.EvtTurnAdvanced:
-						l.state.Cursor++
-					case engine.EvtGameCompleted:
-						l.stopTurnTimer()
-					}
-				}
-				l.state.Phase = engine.DerivePhase(l.state.Cursor)
-				l.version++
-				l.broadcastState()
+				l.turnTimedOut()
 
-				if hasEvent(events, engine.EvtTurnAdvanced) && !hasEvent(events, engine.EvtGameCompleted) {
-					l.armTurnTimer()
-				}
+			case flushPending:
+				l.flush()
+
+			case releaseSpectators:
+				l.releaseDue()
 
 			case PrimeTimer:
+				if dur, ok := l.turnDuration(); ok && dur <= 0 && !l.state.Paused && !l.state.PreDraft {
+					l.turnTimedOut() // no clock to arm; see PrimeTimer
+					break
+				}
 				l.armTurnTimer()
 
 			case GetState:
+				lag := make(map[string]time.Duration, len(l.clients))
+				for id, c := range l.clients {
+					lag[id] = c.lagNow()
+				}
 				msg.Reply <- View{
 					Version:    l.version,
 					NumClients: len(l.clients),
-					State:      l.state,
+					State:      l.state.Clone(),
+					Log:        slices.Clone(l.log),
+					Lag:        lag,
 				}
 
 			case Shutdown:
+				if msg.ServerShutdown {
+					l.noticeShutdown()
+				}
+				if msg.Close {
+					l.persistClose()
+				}
 				l.shutdown()
 				return
 			}
@@ -210,70 +366,296 @@ func (l *Lobby) loop() {
 
 func (l *Lobby) shutdown() {
 	l.stopTurnTimer()
-	for id, ch := range l.clients {
-		close(ch)
+	if l.spectatorTimer != nil {
+		l.spectatorTimer.Stop()
+	}
+	if l.flushTimer != nil {
+		l.flushTimer.Stop()
+	}
+	for id, c := range l.clients {
+		if c.out != nil {
+			close(c.out)
+		}
+		if c.graceTimer != nil {
+			c.graceTimer.Stop()
+		}
 		delete(l.clients, id)
 	}
 	l.cancel()
+	if l.writer != nil {
+		l.writer.close()
+	}
+}
+
+// handleCommand runs cmd through the engine and, on success, folds the events
+// into lobby state, broadcasts, and re-arms the turn timer. Engine errors go
+// back to the sender only.
+func (l *Lobby) handleCommand(clientID string, cmd engine.Command) error {
+	return l.runCommand(clientID, cmd, "")
+}
+
+// runCommand is handleCommand with a reason to tag the snapshot with.
+func (l *Lobby) runCommand(clientID string, cmd engine.Command, reason string) error {
+	log.Printf("FromClient: cursor=%d cmd=%s", l.state.Cursor, cmd.Type)
+	switch {
+	case cmd.Type == engine.CmdPauseDraft:
+		// Freeze whatever is left on the clock (at least 1ms, so a clock
+		// that was about to fire still fires after the resume)
+		if !l.deadline.IsZero() {
+			cmd.Millis = int(max(time.Until(l.deadline).Milliseconds(), 1))
+		}
+	case l.state.TimeBankActive:
+		// Charge whatever the team spent on its reserve to this turn
+		cmd.Millis = int(time.Since(l.bankStart).Milliseconds())
+	}
+	events, _, err := engine.Apply(l.state.Clone(), cmd) // Apply writes into the maps it's given
+	if err != nil {
+		log.Printf("ApplyError: client=%s err=%v", clientID, err)
+		// Send error ONLY to this client; don't broadcast
+		l.sendTo(clientID, types.ServerMessage{
+			Type:  "Error",
+			Error: err.Error(),
+		})
+		return err
+	}
+
+	// Success path: log + fold the events, broadcast snapshot
+	l.record(events)
+	if hasEvent(events, engine.EvtTurnAdvanced) {
+		l.redo = nil // a new action invalidates anything undone
+	}
+	for _, e := range events {
+		switch e.Type {
+		case engine.EvtGameCompleted:
+			l.stopTurnTimer()
+		case engine.EvtTimeBankStarted:
+			l.bankStart = time.Now()
+		case engine.EvtDraftPaused:
+			l.stopTurnTimer()
+			l.timerGen++ // a fire already in the inbox is now stale
+		case engine.EvtDraftResumed:
+			left := time.Duration(e.Millis) * time.Millisecond
+			if l.state.TimeBankActive {
+				// Pause time doesn't come out of the reserve
+				step, _ := engine.CurrentStep(l.state)
+				used := time.Duration(engine.TimeBankLeft(l.state, step.Team))*time.Millisecond - left
+				l.bankStart = time.Now().Add(-used)
+			}
+			l.armTimer(left)
+		}
+	}
+
+	// (Re)arm timer if turn advanced and game not completed, the draft just
+	// started, a fresh draft started for the next game, or the team on turn
+	// fell back to its timebank. Done before broadcasting so the snapshot
+	// carries the new deadline.
+	if hasEvent(events, engine.EvtTurnAdvanced) && !hasEvent(events, engine.EvtGameCompleted) ||
+		hasEvent(events, engine.EvtNextGameStarted) || hasEvent(events, engine.EvtTimerStarted) ||
+		hasEvent(events, engine.EvtTimeBankStarted) {
+		l.armTurnTimer()
+	}
+	l.broadcast(reason)
+	return nil
+}
+
+// record appends one command's events to the log and folds them into state.
+func (l *Lobby) record(events []engine.Event) {
+	if len(events) == 0 {
+		return
+	}
+	l.batch++
+	now := time.Now().UTC()
+	from := len(l.log)
+	for _, e := range events {
+		l.log = append(l.log, engine.LogEntry{Ordinal: len(l.log) + 1, Batch: l.batch, At: now, Event: e})
+		l.state = engine.Fold(l.state, e)
+	}
+	l.persist(l.log[from:])
+}
+
+// turnTimedOut handles the turn clock running out: a team with reserve left
+// moves onto its timebank first, otherwise the turn is auto-resolved.
+func (l *Lobby) turnTimedOut() {
+	if !l.state.Trading && !l.state.TimeBankActive {
+		if step, done := engine.CurrentStep(l.state); !done && engine.TimeBankLeft(l.state, step.Team) > 0 {
+			_ = l.handleCommand("", engine.Command{Type: engine.CmdStartTimeBank})
+			return
+		}
+	}
+	if err := l.handleCommand("", engine.Command{Type: engine.CmdTimeoutAdvance}); err != nil {
+		// Only happens when every champion is banned, picked or locked
+		log.Printf("timer: timeout advance failed cursor=%d err=%v", l.state.Cursor, err)
+	}
 }
 
 // ---- Outbound helpers ----
 
 func (l *Lobby) sendTo(clientID string, m types.ServerMessage) {
-	ch, ok := l.clients[clientID]
-	if !ok {
+	c, ok := l.clients[clientID]
+	if !ok || c.out == nil {
 		return
 	}
+	state := isStateMsg(m)
+	if len(c.queued) > 0 {
+		// Keep the queue in order; a state update is left to the pending
+		// snapshot that follows it
+		if !state {
+			l.queue(clientID, c, m)
+		}
+		return
+	}
+	if state && !c.behindSince.IsZero() && m.Type == "StatePatch" {
+		// A patch won't apply to what a lagging client last got; the whole
+		// state will
+		m = l.snapshotFor(c)
+		m.Reason = "Coalesced"
+	}
 	select {
-	case ch <- m:
+	case c.out <- m:
+		if state {
+			c.caughtUp()
+		}
 	default:
-		// slow client: drop & remove
-		close(ch)
-		delete(l.clients, clientID)
+		if state {
+			l.behind(clientID, c) // coalesced, see backpressure.go
+			return
+		}
+		l.queue(clientID, c, m)
 	}
 }
 
-func (l *Lobby) broadcastState() {
+func (l *Lobby) snapshot(reason string) types.ServerMessage {
+	now := time.Now().UTC()
+	state := l.state.Clone() // writers marshal this off the lobby goroutine
 	msg := types.ServerMessage{
-		Type:    "StateSnapshot",
-		Version: l.version,
-		State:   &l.state,
+		Type:       "StateSnapshot",
+		Version:    l.version,
+		State:      &state,
+		Presence:   l.presence(),
+		ServerTime: now,
+		Reason:     reason,
 	}
-	for id := range l.clients {
-		l.sendTo(id, msg)
+	if !l.deadline.IsZero() {
+		msg.Timer = &types.TurnTimer{
+			Deadline:    l.deadline,
+			RemainingMs: max(l.deadline.Sub(now).Milliseconds(), 0),
+			Gen:         l.timerGen,
+			TimeBank:    l.state.TimeBankActive,
+		}
+	}
+	return msg
+}
+
+func (l *Lobby) broadcastState() {
+	l.broadcast("")
+}
+
+// broadcast sends a snapshot to everyone, tagged with why it was sent when
+// that isn't just "something happened" (e.g. "Undo"). Each client only sees
+// the hovers it may; spectators get it on their delay. Every broadcast is a
+// new version, presence changes included, so a patch always goes from one
+// version to the next.
+func (l *Lobby) broadcast(reason string) {
+	l.version++
+	l.persistVersion()
+	prev, msg := l.history[len(l.history)-1], l.snapshot(reason)
+	l.remember(msg)
+	delay := l.spectatorDelay() > 0
+	patches := make(map[string]types.ServerMessage) // one per audience
+	for id, c := range l.clients {
+		switch {
+		case c.spectator && delay:
+		case c.patches:
+			key := l.audience(c)
+			patch, ok := patches[key]
+			if !ok {
+				patch = toPatch(l.visibleTo(c, prev), l.visibleTo(c, msg))
+				patches[key] = patch
+			}
+			l.sendTo(id, patch)
+		default:
+			l.sendTo(id, l.visibleTo(c, msg))
+		}
+	}
+	if delay {
+		held := l.hoversForSpectators(msg)
+		l.holdForSpectators(held, toPatch(l.hoversForSpectators(prev), held))
+	}
+}
+
+// noticeShutdown tells everyone the server is going away, with the last
+// state they're allowed to see.
+func (l *Lobby) noticeShutdown() {
+	live, delayed := l.snapshot(""), l.spectatorSnapshot()
+	live.Type, delayed.Type = "ServerShuttingDown", "ServerShuttingDown"
+	for id, c := range l.clients {
+		if c.spectator {
+			l.sendTo(id, delayed)
+		} else {
+			l.sendTo(id, l.visibleTo(c, live))
+		}
 	}
 }
 
 // ---- Timers ----
 
 func (l *Lobby) armTurnTimer() {
-	step := engine.GameOrder[l.state.Cursor]
-	var sec int
-	if step.Action == engine.ActionPick {
-		sec = l.state.Rules.PickTimerSec
-	} else {
-		sec = l.state.Rules.BanTimerSec
+	if l.state.Paused {
+		return // the clock is frozen until ResumeDraft
+	}
+	if l.state.PreDraft {
+		l.stopTurnTimer() // e.g. the next game of a series, waiting on StartGame
+		return
+	}
+	dur, ok := l.turnDuration()
+	if !ok {
+		l.stopTurnTimer()
+		return
 	}
+	l.armTimer(dur)
+}
 
+// turnDuration is how long a freshly armed clock runs for the turn (or
+// timebank, or trade window) state is on. ok is false once the draft is done.
+func (l *Lobby) turnDuration() (time.Duration, bool) {
+	if l.state.Trading {
+		// Post-draft trade window; expiry closes it and completes the game
+		return time.Duration(l.state.Rules.TradeTimerSec) * time.Second, true
+	}
+	step, done := engine.CurrentStep(l.state)
+	switch {
+	case done:
+		return 0, false
+	case l.state.TimeBankActive:
+		return time.Duration(engine.TimeBankLeft(l.state, step.Team)) * time.Millisecond, true
+	case step.Action == engine.ActionPick:
+		return time.Duration(l.state.Rules.PickTimerSec) * time.Second, true
+	default:
+		return time.Duration(l.state.Rules.BanTimerSec) * time.Second, true
+	}
+}
+
+// armTimer (re)starts the turn clock for exactly dur.
+func (l *Lobby) armTimer(dur time.Duration) {
 	// Guard: don’t arm zero/negative timers
-	if sec <= 0 {
+	if dur <= 0 {
 		l.stopTurnTimer() // ensure any previous timer is stopped
 
-		log.Printf("timer: not arming (sec=%d) cursor=%d phase=%s", sec, l.state.Cursor, l.state.Phase)
+		log.Printf("timer: not arming (dur=%s) cursor=%d phase=%s", dur, l.state.Cursor, l.state.Phase)
 		return
 	}
 
-	dur := time.Duration(sec) * time.Second
 	l.timerGen++
 	gen := l.timerGen
+	l.deadline = time.Now().Add(dur).UTC()
 
 	if l.turnTimer != nil {
 		l.turnTimer.Stop()
 	}
+	log.Printf("timer: armed %s cursor=%d phase=%s gen=%d", dur, l.state.Cursor, l.state.Phase, gen)
 	l.turnTimer = time.AfterFunc(dur, func() {
 		select {
 		case l.inbox <- TimerFired{Gen: gen}:
-			log.Printf("timer: armed %ds cursor=%d phase=%s gen=%d", sec, l.state.Cursor, l.state.Phase, gen)
 		case <-l.ctx.Done():
 			return
 		}
@@ -284,7 +666,12 @@ func (l *Lobby) stopTurnTimer() {
 	if l.turnTimer != nil {
 		l.turnTimer.Stop()
 	}
+	l.deadline = time.Time{}
 }
 
 // Expose the inbox so tests or WS layer can send messages.
 func (l *Lobby) Inbox() chan<- Msg { return l.inbox }
+
+// Done is closed once the lobby's goroutine has exited; nothing reads the
+// inbox after that.
+func (l *Lobby) Done() <-chan struct{} { return l.done }
@@ -2,10 +2,15 @@ package lobby
 
 import (
 	"context"
+	"maps"
+	"math/rand/v2"
+	"reflect"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/store"
 	"github.com/DoyleJ11/lol-draft-backend/internal/types"
 )
 
@@ -75,7 +80,7 @@ func TestLobby_Pick_BroadcastsSnapshotAndVersionIncrements(t *testing.T) {
 
 	// 5) send a legal pick as a FromClient message
 	cmd := engine.Command{Type: engine.CmdLockPick, Team: engine.TeamBlue, ChampionID: 266}
-	l.Inbox() <- FromClient{Cmd: cmd}
+	l.Inbox() <- ServerCommand{Cmd: cmd}
 
 	// 6) expect a new snapshot with version=1 and the pick applied
 	next := recvSnapshot(t, clientOut, 100*time.Millisecond)
@@ -91,7 +96,7 @@ func TestLobby_Pick_BroadcastsSnapshotAndVersionIncrements(t *testing.T) {
 	l.Inbox() <- Shutdown{}
 }
 
-func TestLobby_DropSlowClient(t *testing.T) {
+func TestLobby_SlowClientMissesMessagesThenSyncs(t *testing.T) {
 	init := engine.NewEmptyState()
 	init.Cursor = 6
 
@@ -104,14 +109,24 @@ func TestLobby_DropSlowClient(t *testing.T) {
 	l.Inbox() <- Join{ClientID: "ch1", Outbox: clientOut}
 
 	cmd := engine.Command{Type: engine.CmdLockPick, Team: engine.TeamBlue, ChampionID: 266}
-	l.Inbox() <- FromClient{Cmd: cmd}
+	l.Inbox() <- ServerCommand{Cmd: cmd}
 
 	reply := make(chan View, 1)
 	l.Inbox() <- GetState{Reply: reply}
 	view := recvView(t, reply, 100*time.Millisecond)
 
-	if view.NumClients != 0 {
-		t.Fatalf("expected slow client to be dropped; NumClients=%d", view.NumClients)
+	if view.NumClients != 1 {
+		t.Fatalf("expected slow client to be kept; NumClients=%d", view.NumClients)
+	}
+
+	// Only the welcome made it; the pick waits as a pending snapshot
+	welcome := recvSnapshot(t, clientOut, 100*time.Millisecond)
+	recvNoSnapshot(t, clientOut, 50*time.Millisecond)
+
+	l.Inbox() <- Sync{ClientID: "ch1", SinceVersion: welcome.Version}
+	caught := recvSnapshot(t, clientOut, 100*time.Millisecond)
+	if caught.Reason != "Sync" || caught.Version != view.Version || len(caught.State.Picks[engine.TeamBlue]) != 1 {
+		t.Fatalf("want a Sync snapshot with the pick at v%d, got %+v", view.Version, caught)
 	}
 }
 
@@ -144,6 +159,31 @@ func TestLobby_TimerFires_TimeoutAdvanceEmitsSnapshot(t *testing.T) {
 	}
 }
 
+func TestLobby_ZeroTimer_OnlyPrimeTimesOut(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Cursor = 5
+	init.Rules.PickTimerSec = 0
+	init.Rules.BanTimerSec = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+
+	out := make(chan types.ServerMessage, 4)
+	l.Inbox() <- Join{ClientID: "ch1", Outbox: out}
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+
+	// Priming times the ban out; the pick it moves to still has no clock,
+	// so nothing else happens on its own
+	l.Inbox() <- PrimeTimer{}
+	next := recvSnapshot(t, out, 500*time.Millisecond)
+	if next.State.Cursor != 6 || next.Timer != nil {
+		t.Fatalf("want cursor 6 with no clock, got cursor=%d timer=%+v", next.State.Cursor, next.Timer)
+	}
+	recvNoSnapshot(t, out, 100*time.Millisecond)
+}
+
 func TestLobby_TimerGen_DropsStaleFires(t *testing.T) {
 	init := engine.NewEmptyState()
 	init.Cursor = 5 // Ban Step
@@ -164,7 +204,7 @@ func TestLobby_TimerGen_DropsStaleFires(t *testing.T) {
 	l.Inbox() <- PrimeTimer{}
 
 	// BEFORE #1 fires, advance via a legal BAN
-	l.Inbox() <- FromClient{Cmd: engine.Command{
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{
 		Type:       engine.CmdBanChampion,
 		Team:       engine.GameOrder[init.Cursor].Team,
 		ChampionID: 55,
@@ -174,7 +214,7 @@ func TestLobby_TimerGen_DropsStaleFires(t *testing.T) {
 	postBan := recvSnapshot(t, out, 500*time.Millisecond)
 
 	step := engine.GameOrder[postBan.State.Cursor]
-	l.Inbox() <- FromClient{Cmd: engine.Command{
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{
 		Type:       engine.CmdHoverChampion,
 		Team:       step.Team, // must match current pick team
 		SeatID:     "",        // important: TimerFired uses "", so we hover under ""
@@ -217,3 +257,1320 @@ func TestLobby_Shutdown_StopsTimer_NoFire(t *testing.T) {
 	// Now assert no *new* snapshot shows up (or channel is closed)
 	recvNoSnapshot(t, out, 700*time.Millisecond) // < PickTimerSec (1s)
 }
+
+func TestLobby_StartNextGame_SnapshotShowsSeriesAndFearless(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.Fearless = engine.FearlessHard
+	init.Rules.PickTimerSec = 0
+	init.Rules.BanTimerSec = 0
+	init.Series = engine.NewSeries(3)
+	init.Cursor = len(engine.GameOrder) - 1
+	init.Picks = map[engine.Team][]int{engine.TeamBlue: {1, 2, 3, 4}, engine.TeamRed: {5, 6, 7, 8}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+
+	out := make(chan types.ServerMessage, 4)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+
+	// Last pick of game 1 (red)
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdLockPick, Team: engine.TeamRed, ChampionID: 9}}
+	done := recvSnapshot(t, out, 100*time.Millisecond)
+	if done.State.Phase != engine.PhaseDone {
+		t.Fatalf("want phase done after last pick, got %v", done.State.Phase)
+	}
+
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdStartNextGame, Team: engine.TeamBlue}}
+	next := recvSnapshot(t, out, 100*time.Millisecond)
+	if next.State.Series.GameIndex != 1 || next.State.Series.Score[engine.TeamBlue] != 1 {
+		t.Fatalf("unexpected series in snapshot: %+v", next.State.Series)
+	}
+	if next.State.Phase != engine.PhaseBan1 || next.State.Cursor != 0 {
+		t.Fatalf("want a fresh ban1 draft, got phase=%v cursor=%d", next.State.Phase, next.State.Cursor)
+	}
+	if !slices.Contains(next.State.Fearless[engine.TeamRed], 9) || !slices.Contains(next.State.Fearless[engine.TeamBlue], 1) {
+		t.Fatalf("expected game 1 picks fearless-locked, got %v", next.State.Fearless)
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_StartGame_ArmsFirstTimer(t *testing.T) {
+	init := engine.NewPreDraftState()
+	init.Rules.BanTimerSec = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	if first := recvSnapshot(t, out, 100*time.Millisecond); first.State.Phase != engine.PhaseLobby {
+		t.Fatalf("want lobby phase on join, got %v", first.State.Phase)
+	}
+
+	for _, cmd := range []engine.Command{
+		{Type: engine.CmdJoinSeat, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdJoinSeat, Team: engine.TeamRed, SeatID: "r1"},
+		{Type: engine.CmdReady, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdReady, Team: engine.TeamRed, SeatID: "r1"},
+	} {
+		l.Inbox() <- ServerCommand{Cmd: cmd}
+		_ = recvSnapshot(t, out, 100*time.Millisecond)
+	}
+
+	// Nothing ticks until the game is started
+	recvNoSnapshot(t, out, 1200*time.Millisecond)
+
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdStartGame}}
+	started := recvSnapshot(t, out, 100*time.Millisecond)
+	if started.State.Phase != engine.PhaseBan1 {
+		t.Fatalf("want ban1 after StartGame, got %v", started.State.Phase)
+	}
+
+	// Ban timer expires with no hover → ban skipped, turn advances
+	skipped := recvSnapshot(t, out, 1500*time.Millisecond)
+	if skipped.State.Cursor != 1 {
+		t.Fatalf("want cursor 1 after first ban timer, got %d", skipped.State.Cursor)
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_TradeWindowClosesOnTimer(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.TradeTimerSec = 1
+	init.Cursor = len(engine.GameOrder) - 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+
+	out := make(chan types.ServerMessage, 4)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdLockPick, Team: engine.TeamRed, ChampionID: 10}}
+	trading := recvSnapshot(t, out, 100*time.Millisecond)
+	if trading.State.Phase != engine.PhaseTrade {
+		t.Fatalf("want trade phase after last pick, got %v", trading.State.Phase)
+	}
+
+	done := recvSnapshot(t, out, 1500*time.Millisecond)
+	if done.State.Phase != engine.PhaseDone {
+		t.Fatalf("want done once the trade timer expires, got %v", done.State.Phase)
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+// recvType reads messages until one of the wanted type shows up.
+func recvType(t *testing.T, ch <-chan types.ServerMessage, typ string, within time.Duration) types.ServerMessage {
+	t.Helper()
+	deadline := time.After(within)
+	for {
+		select {
+		case m, ok := <-ch:
+			if !ok {
+				t.Fatalf("client outbox closed waiting for %s", typ)
+			}
+			if m.Type == typ {
+				return m
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s", typ)
+		}
+	}
+}
+
+func TestLobby_ClaimSeat_GatesCommandsBySeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewPreDraftState())
+
+	blue := make(chan types.ServerMessage, 8)
+	red := make(chan types.ServerMessage, 8)
+	spectator := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "blue", Outbox: blue}
+	l.Inbox() <- Join{ClientID: "red", Outbox: red}
+	l.Inbox() <- Join{ClientID: "spec", Outbox: spectator}
+
+	l.Inbox() <- ClaimSeat{ClientID: "blue", Team: engine.TeamBlue, SeatID: "b1", Name: "Jack"}
+	claimed := recvType(t, blue, "SeatClaimed", 200*time.Millisecond)
+	if claimed.Seat == nil || claimed.Seat.Token == "" || claimed.Seat.SeatID != "b1" {
+		t.Fatalf("expected a seat token, got %+v", claimed.Seat)
+	}
+	l.Inbox() <- ClaimSeat{ClientID: "red", Team: engine.TeamRed, SeatID: "r1"}
+	_ = recvType(t, red, "SeatClaimed", 200*time.Millisecond)
+
+	// Spectator can't act at all, and can't steal b1 without its token
+	l.Inbox() <- FromClient{ClientID: "spec", Cmd: engine.Command{Type: engine.CmdReady, Team: engine.TeamBlue, SeatID: "b1"}}
+	_ = recvType(t, spectator, "Unauthorized", 200*time.Millisecond)
+	l.Inbox() <- ClaimSeat{ClientID: "spec", Team: engine.TeamBlue, SeatID: "b1"}
+	_ = recvType(t, spectator, "Unauthorized", 200*time.Millisecond)
+
+	// Red can't ready blue up
+	l.Inbox() <- FromClient{ClientID: "red", Cmd: engine.Command{Type: engine.CmdReady, Team: engine.TeamBlue, SeatID: "b1"}}
+	_ = recvType(t, red, "Unauthorized", 200*time.Millisecond)
+
+	// Blue readies without naming the seat; the lobby fills it in
+	l.Inbox() <- FromClient{ClientID: "blue", Cmd: engine.Command{Type: engine.CmdReady, Team: engine.TeamBlue}}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	if view := recvView(t, reply, 100*time.Millisecond); !view.State.Ready[engine.TeamBlue] || view.State.Ready[engine.TeamRed] {
+		t.Fatalf("expected only blue ready, got %v", view.State.Ready)
+	}
+
+	// A reconnecting client gets b1 back with the token
+	again := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "blue-2", Outbox: again}
+	l.Inbox() <- ClaimSeat{ClientID: "blue-2", Team: engine.TeamBlue, SeatID: "b1", Token: claimed.Seat.Token}
+	_ = recvType(t, again, "SeatClaimed", 200*time.Millisecond)
+
+	l.Inbox() <- ClaimSeat{ClientID: "spec", Team: engine.TeamRed, SeatID: "r1", Token: claimed.Seat.Token}
+	_ = recvType(t, spectator, "Unauthorized", 200*time.Millisecond)
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_ClaimSeat_RejectsHeldSeatWithoutRoster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Draft running with no pre-draft: claims only pin a connection to a side
+	l := NewLobby(ctx, engine.NewEmptyState())
+	first := make(chan types.ServerMessage, 8)
+	second := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: first}
+	l.Inbox() <- Join{ClientID: "c2", Outbox: second}
+
+	l.Inbox() <- ClaimSeat{ClientID: "c1", Team: engine.TeamBlue, SeatID: "b1"}
+	claimed := recvType(t, first, "SeatClaimed", 200*time.Millisecond)
+
+	l.Inbox() <- ClaimSeat{ClientID: "c2", Team: engine.TeamBlue, SeatID: "b1"}
+	if msg := recvType(t, second, "Unauthorized", 200*time.Millisecond); msg.Error != ErrSeatClaimed.Error() {
+		t.Fatalf("want %q for a seat someone holds, got %q", ErrSeatClaimed, msg.Error)
+	}
+
+	// Its token still moves the seat over, as with a roster
+	l.Inbox() <- ClaimSeat{ClientID: "c2", Team: engine.TeamBlue, SeatID: "b1", Token: claimed.Seat.Token}
+	_ = recvType(t, second, "SeatClaimed", 200*time.Millisecond)
+	l.Inbox() <- FromClient{ClientID: "c1", Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+	if msg := recvType(t, first, "Unauthorized", 200*time.Millisecond); msg.Error != ErrNoSeat.Error() {
+		t.Fatalf("want the old holder unseated, got %q", msg.Error)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_StartGame_HostOrCaptainOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewPreDraftState())
+	outs := map[string]chan types.ServerMessage{}
+	for _, seat := range []struct {
+		team engine.Team
+		id   string
+	}{{engine.TeamBlue, "b1"}, {engine.TeamBlue, "b2"}, {engine.TeamRed, "r1"}} {
+		outs[seat.id] = make(chan types.ServerMessage, 16)
+		l.Inbox() <- Join{ClientID: seat.id, Outbox: outs[seat.id]}
+		l.Inbox() <- ClaimSeat{ClientID: seat.id, Team: seat.team, SeatID: seat.id}
+		_ = recvType(t, outs[seat.id], "SeatClaimed", 200*time.Millisecond)
+	}
+	l.Inbox() <- FromClient{ClientID: "b1", Cmd: engine.Command{Type: engine.CmdReady, Team: engine.TeamBlue}}
+	l.Inbox() <- FromClient{ClientID: "r1", Cmd: engine.Command{Type: engine.CmdReady, Team: engine.TeamRed}}
+
+	// b2 isn't blue's captain, and an empty client ID is nobody
+	l.Inbox() <- FromClient{ClientID: "b2", Cmd: engine.Command{Type: engine.CmdStartGame}}
+	if msg := recvType(t, outs["b2"], "Unauthorized", 200*time.Millisecond); msg.Error != ErrNotHostOrCaptain.Error() {
+		t.Fatalf("want %q, got %q", ErrNotHostOrCaptain, msg.Error)
+	}
+	l.Inbox() <- FromClient{Cmd: engine.Command{Type: engine.CmdStartGame}}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	if view := recvView(t, reply, 100*time.Millisecond); !view.State.PreDraft {
+		t.Fatalf("draft started without the host or a captain")
+	}
+
+	l.Inbox() <- FromClient{ClientID: "r1", Cmd: engine.Command{Type: engine.CmdStartGame}}
+	l.Inbox() <- GetState{Reply: reply}
+	if view := recvView(t, reply, 100*time.Millisecond); view.State.PreDraft {
+		t.Fatalf("red's captain could not start the draft")
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_StartNextGame_CaptainsMustAgreeOnWinner(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.PickTimerSec = 30
+	init.Rules.BanTimerSec = 30
+	init.Series = engine.NewSeries(3)
+	init.Cursor = len(engine.GameOrder) - 1
+	init.Picks = map[engine.Team][]int{engine.TeamBlue: {1, 2, 3, 4, 5}, engine.TeamRed: {6, 7, 8, 9}}
+	init.Seats = map[engine.Team][]engine.Seat{engine.TeamBlue: {{ID: "b1"}}, engine.TeamRed: {{ID: "r1"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+	outs := map[string]chan types.ServerMessage{}
+	for _, team := range []engine.Team{engine.TeamBlue, engine.TeamRed} {
+		id := init.Seats[team][0].ID
+		outs[id] = make(chan types.ServerMessage, 16)
+		l.Inbox() <- Join{ClientID: id, Outbox: outs[id]}
+		l.Inbox() <- ClaimSeat{ClientID: id, Team: team, SeatID: id, Token: l.signSeatToken(team, id)}
+		_ = recvType(t, outs[id], "SeatClaimed", 200*time.Millisecond)
+	}
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdLockPick, Team: engine.TeamRed, ChampionID: 10}}
+
+	series := func() engine.Series {
+		reply := make(chan View, 1)
+		l.Inbox() <- GetState{Reply: reply}
+		return recvView(t, reply, 100*time.Millisecond).State.Series
+	}
+	report := func(from string, winner engine.Team) {
+		l.Inbox() <- FromClient{ClientID: from, Cmd: engine.Command{Type: engine.CmdStartNextGame, Team: winner}}
+	}
+
+	// Red's captain claims the game: blue hears about it, nothing is credited
+	report("r1", engine.TeamRed)
+	if msg := recvType(t, outs["b1"], "ResultReported", 200*time.Millisecond); msg.Winner != "red" {
+		t.Fatalf("want blue told red claims the win, got %+v", msg)
+	}
+	if sr := series(); sr.GameIndex != 0 || sr.Score[engine.TeamRed] != 0 {
+		t.Fatalf("one captain's report alone moved the series: %+v", sr)
+	}
+
+	// Blue disagrees; still nothing
+	report("b1", engine.TeamBlue)
+	_ = recvType(t, outs["r1"], "ResultReported", 200*time.Millisecond)
+	if sr := series(); sr.GameIndex != 0 {
+		t.Fatalf("conflicting reports moved the series: %+v", sr)
+	}
+
+	// Red comes round to blue's result
+	report("r1", engine.TeamBlue)
+	if sr := series(); sr.GameIndex != 1 || sr.Score[engine.TeamBlue] != 1 || sr.Score[engine.TeamRed] != 0 {
+		t.Fatalf("want blue credited once both captains agree, got %+v", sr)
+	}
+
+	// Game 2 waits in pre-draft for the captains to ready up; no clock yet
+	for {
+		snap := recvType(t, outs["b1"], "StateSnapshot", 200*time.Millisecond)
+		if snap.State.Series.GameIndex != 1 {
+			continue
+		}
+		if !snap.State.PreDraft || snap.Timer != nil {
+			t.Fatalf("want game 2 in pre-draft with no clock, got phase=%v timer=%+v", snap.State.Phase, snap.Timer)
+		}
+		break
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_Resume_KeepsSeatThroughGrace(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewPreDraftState(), WithReconnectGrace(100*time.Millisecond))
+
+	first := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "cap", Outbox: first}
+	welcome := recvSnapshot(t, first, 200*time.Millisecond)
+	if welcome.Session == nil || welcome.Session.Token == "" || welcome.Session.ClientID != "cap" {
+		t.Fatalf("expected a session on the first snapshot, got %+v", welcome.Session)
+	}
+	l.Inbox() <- ClaimSeat{ClientID: "cap", Team: engine.TeamBlue, SeatID: "b1"}
+	_ = recvType(t, first, "SeatClaimed", 200*time.Millisecond)
+
+	// Wi-Fi blips: seat is held as reconnecting rather than vacant
+	l.Inbox() <- Leave{ClientID: "cap", Outbox: first}
+	watcher := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "watch", Outbox: watcher}
+	snap := recvSnapshot(t, watcher, 200*time.Millisecond)
+	if want := []types.SeatPresence{{Team: "blue", SeatID: "b1", Status: types.SeatReconnecting}}; !slices.Equal(snap.Presence, want) {
+		t.Fatalf("presence = %+v, want %+v", snap.Presence, want)
+	}
+
+	// Resume on a new socket gets the same client ID and can still act for b1
+	second := make(chan types.ServerMessage, 8)
+	idReply := make(chan string, 1)
+	l.Inbox() <- Resume{Session: welcome.Session.Token, ClientID: "fresh", Outbox: second, Reply: idReply}
+	if id := <-idReply; id != "cap" {
+		t.Fatalf("resumed as %q, want cap", id)
+	}
+	if snap := recvSnapshot(t, second, 200*time.Millisecond); snap.Session == nil || !snap.Session.Resumed {
+		t.Fatalf("expected a resumed session, got %+v", snap.Session)
+	}
+	// Let the old grace timer pass; it must not vacate the seat
+	time.Sleep(150 * time.Millisecond)
+	l.Inbox() <- FromClient{ClientID: "cap", Cmd: engine.Command{Type: engine.CmdReady, Team: engine.TeamBlue}}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	if view := recvView(t, reply, 100*time.Millisecond); !view.State.Ready[engine.TeamBlue] {
+		t.Fatalf("resumed captain could not ready up")
+	}
+
+	// Gone for longer than the grace: the seat shows vacant and the session is dead
+	l.Inbox() <- Leave{ClientID: "cap", Outbox: second}
+	time.Sleep(150 * time.Millisecond)
+	l.Inbox() <- GetState{Reply: reply}
+	if view := recvView(t, reply, 100*time.Millisecond); view.NumClients != 1 {
+		t.Fatalf("expected the captain to be dropped, got %d clients", view.NumClients)
+	}
+	third := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Resume{Session: welcome.Session.Token, ClientID: "late", Outbox: third, Reply: idReply}
+	if id := <-idReply; id != "late" {
+		t.Fatalf("expired session resumed as %q", id)
+	}
+	if snap := recvSnapshot(t, third, 200*time.Millisecond); snap.Presence[0].Status != types.SeatVacant {
+		t.Fatalf("expected b1 vacant, got %+v", snap.Presence)
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_TimeBankRunsBeforeTimeout(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.BanTimerSec = 1
+	init.Rules.TimeBankSec = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+	l.Inbox() <- PrimeTimer{}
+
+	// Turn clock runs out → blue drops onto its reserve, still on the same turn
+	banked := recvSnapshot(t, out, 1500*time.Millisecond)
+	if !banked.State.TimeBankActive || banked.State.Cursor != 0 {
+		t.Fatalf("want blue on its timebank at cursor 0, got active=%v cursor=%d", banked.State.TimeBankActive, banked.State.Cursor)
+	}
+
+	// Reserve runs out too → the ban is skipped and the bank is spent
+	skipped := recvSnapshot(t, out, 1500*time.Millisecond)
+	if skipped.State.TimeBankActive || skipped.State.Cursor != 1 {
+		t.Fatalf("want turn advanced off the timebank, got active=%v cursor=%d", skipped.State.TimeBankActive, skipped.State.Cursor)
+	}
+	if left := engine.TimeBankLeft(*skipped.State, engine.TeamBlue); left != 0 {
+		t.Fatalf("want blue's bank empty, got %dms", left)
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_SnapshotDeadlineMovesEveryTurn(t *testing.T) {
+	init := engine.NewPreDraftState()
+	init.Rules.BanTimerSec = 20
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	if first := recvSnapshot(t, out, 100*time.Millisecond); first.Timer != nil || first.ServerTime.IsZero() {
+		t.Fatalf("want server time but no timer before the draft, got timer=%+v time=%v", first.Timer, first.ServerTime)
+	}
+
+	for _, cmd := range []engine.Command{
+		{Type: engine.CmdJoinSeat, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdJoinSeat, Team: engine.TeamRed, SeatID: "r1"},
+		{Type: engine.CmdReady, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdReady, Team: engine.TeamRed, SeatID: "r1"},
+	} {
+		l.Inbox() <- ServerCommand{Cmd: cmd}
+		_ = recvSnapshot(t, out, 100*time.Millisecond)
+	}
+
+	var prev *types.TurnTimer
+	for i, cmd := range []engine.Command{
+		{Type: engine.CmdStartGame},
+		{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1},
+		{Type: engine.CmdBanChampion, Team: engine.TeamRed, ChampionID: 2},
+	} {
+		time.Sleep(5 * time.Millisecond) // so deadlines can't tie
+		l.Inbox() <- ServerCommand{Cmd: cmd}
+		snap := recvSnapshot(t, out, 100*time.Millisecond)
+		timer := snap.Timer
+		if timer == nil {
+			t.Fatalf("step %d: snapshot has no timer", i)
+		}
+		if timer.RemainingMs <= 0 || timer.RemainingMs > 20_000 {
+			t.Fatalf("step %d: remaining %dms out of range", i, timer.RemainingMs)
+		}
+		if got := timer.Deadline.Sub(snap.ServerTime).Milliseconds(); got != timer.RemainingMs {
+			t.Fatalf("step %d: remaining %dms disagrees with deadline-server_time %dms", i, timer.RemainingMs, got)
+		}
+		if prev != nil && (!timer.Deadline.After(prev.Deadline) || timer.Gen <= prev.Gen) {
+			t.Fatalf("step %d: deadline/gen did not move: prev=%+v now=%+v", i, prev, timer)
+		}
+		prev = timer
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_PauseFreezesClockForHostOnly(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.BanTimerSec = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+	host := make(chan types.ServerMessage, 8)
+	player := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "host", Outbox: host}
+	l.Inbox() <- Join{ClientID: "blue", Outbox: player}
+	_ = recvSnapshot(t, host, 100*time.Millisecond)
+	_ = recvSnapshot(t, player, 100*time.Millisecond)
+	l.Inbox() <- ClaimSeat{ClientID: "blue", Team: engine.TeamBlue, SeatID: "b1"}
+	_ = recvType(t, player, "SeatClaimed", 200*time.Millisecond)
+
+	l.Inbox() <- ClaimHost{ClientID: "host", Token: "nope"}
+	_ = recvType(t, host, "Unauthorized", 200*time.Millisecond)
+	l.Inbox() <- ClaimHost{ClientID: "host", Token: l.HostToken()}
+	_ = recvType(t, host, "HostClaimed", 200*time.Millisecond)
+
+	l.Inbox() <- PrimeTimer{}
+	time.Sleep(400 * time.Millisecond)
+
+	// Players can't pause
+	l.Inbox() <- FromClient{ClientID: "blue", Cmd: engine.Command{Type: engine.CmdPauseDraft}}
+	_ = recvType(t, player, "Unauthorized", 200*time.Millisecond)
+
+	l.Inbox() <- FromClient{ClientID: "host", Cmd: engine.Command{Type: engine.CmdPauseDraft}}
+	paused := recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	if !paused.State.Paused || paused.Timer != nil {
+		t.Fatalf("want paused with no running timer, got paused=%v timer=%+v", paused.State.Paused, paused.Timer)
+	}
+	frozen := paused.State.PausedMs
+	if frozen <= 0 || frozen > 700 {
+		t.Fatalf("want ~600ms frozen, got %dms", frozen)
+	}
+
+	// Well past the original deadline: nothing fires, bans are refused
+	time.Sleep(900 * time.Millisecond)
+	l.Inbox() <- FromClient{ClientID: "blue", Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+	if e := recvType(t, player, "Error", 200*time.Millisecond); e.Error != engine.ErrDraftPaused.Error() {
+		t.Fatalf("want %q, got %q", engine.ErrDraftPaused, e.Error)
+	}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	if v := recvView(t, reply, 100*time.Millisecond); v.State.Cursor != 0 {
+		t.Fatalf("timer ran while paused, cursor=%d", v.State.Cursor)
+	}
+
+	l.Inbox() <- FromClient{ClientID: "host", Cmd: engine.Command{Type: engine.CmdResumeDraft}}
+	resumed := recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	if resumed.Timer == nil || resumed.Timer.RemainingMs > int64(frozen) || resumed.Timer.RemainingMs < int64(frozen)-50 {
+		t.Fatalf("want the frozen %dms back, got %+v", frozen, resumed.Timer)
+	}
+	// ...and it runs out on that, not on a fresh second
+	skipped := recvType(t, host, "StateSnapshot", time.Duration(frozen+200)*time.Millisecond)
+	if skipped.State.Cursor != 1 {
+		t.Fatalf("want the ban skipped after the resume, got cursor %d", skipped.State.Cursor)
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_UndoRedoLastAction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewEmptyState())
+	host := make(chan types.ServerMessage, 16)
+	player := make(chan types.ServerMessage, 16)
+	l.Inbox() <- Join{ClientID: "host", Outbox: host}
+	l.Inbox() <- Join{ClientID: "p", Outbox: player}
+	l.Inbox() <- ClaimHost{ClientID: "host", Token: l.HostToken()}
+	_ = recvType(t, host, "HostClaimed", 200*time.Millisecond)
+
+	l.Inbox() <- UndoLastAction{ClientID: "host"}
+	if e := recvType(t, host, "Error", 200*time.Millisecond); e.Error != ErrNothingToUndo.Error() {
+		t.Fatalf("want %q, got %q", ErrNothingToUndo, e.Error)
+	}
+
+	for _, cmd := range []engine.Command{
+		{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1},
+		{Type: engine.CmdBanChampion, Team: engine.TeamRed, ChampionID: 2},
+	} {
+		l.Inbox() <- ServerCommand{Cmd: cmd}
+		_ = recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	}
+
+	l.Inbox() <- UndoLastAction{ClientID: "p"}
+	_ = recvType(t, player, "Unauthorized", 200*time.Millisecond)
+
+	l.Inbox() <- UndoLastAction{ClientID: "host"}
+	undone := recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	if undone.Reason != "Undo" || undone.State.Cursor != 1 || !slices.Equal(undone.State.Bans[engine.TeamRed], []int{}) {
+		t.Fatalf("want red's ban rolled back, got reason=%q cursor=%d bans=%v", undone.Reason, undone.State.Cursor, undone.State.Bans)
+	}
+	if undone.Timer == nil {
+		t.Fatalf("expected the clock re-armed for red's turn")
+	}
+
+	l.Inbox() <- RedoAction{ClientID: "host"}
+	redone := recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	if redone.Reason != "Redo" || redone.State.Cursor != 2 || !slices.Equal(redone.State.Bans[engine.TeamRed], []int{2}) {
+		t.Fatalf("want red's ban back, got reason=%q cursor=%d bans=%v", redone.Reason, redone.State.Cursor, redone.State.Bans)
+	}
+
+	// Undo again, then a new action: the redo is gone
+	l.Inbox() <- UndoLastAction{ClientID: "host"}
+	_ = recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamRed, ChampionID: 3}}
+	_ = recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	l.Inbox() <- RedoAction{ClientID: "host"}
+	if e := recvType(t, host, "Error", 200*time.Millisecond); e.Error != ErrNothingToRedo.Error() {
+		t.Fatalf("want %q, got %q", ErrNothingToRedo, e.Error)
+	}
+
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_RedoWhilePausedStaysOnTheStack(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewEmptyState())
+	host := make(chan types.ServerMessage, 16)
+	l.Inbox() <- Join{ClientID: "host", Outbox: host}
+	l.Inbox() <- ClaimHost{ClientID: "host", Token: l.HostToken()}
+	_ = recvType(t, host, "HostClaimed", 200*time.Millisecond)
+
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+	_ = recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	l.Inbox() <- UndoLastAction{ClientID: "host"}
+	_ = recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+
+	// Redo is turned down while paused...
+	l.Inbox() <- FromClient{ClientID: "host", Cmd: engine.Command{Type: engine.CmdPauseDraft}}
+	_ = recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	l.Inbox() <- RedoAction{ClientID: "host"}
+	if e := recvType(t, host, "Error", 200*time.Millisecond); e.Error != engine.ErrDraftPaused.Error() {
+		t.Fatalf("want %q, got %q", engine.ErrDraftPaused, e.Error)
+	}
+
+	// ...and still there once the draft resumes
+	l.Inbox() <- FromClient{ClientID: "host", Cmd: engine.Command{Type: engine.CmdResumeDraft}}
+	_ = recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	l.Inbox() <- RedoAction{ClientID: "host"}
+	redone := recvType(t, host, "StateSnapshot", 200*time.Millisecond)
+	if redone.Reason != "Redo" || !slices.Equal(redone.State.Bans[engine.TeamBlue], []int{1}) {
+		t.Fatalf("want blue's ban redone after the resume, got reason=%q bans=%v", redone.Reason, redone.State.Bans)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+// slowStore holds CreateLobby until release is closed.
+type slowStore struct {
+	store.Store
+	release chan struct{}
+}
+
+func (s slowStore) CreateLobby(ctx context.Context, rec store.Lobby) error {
+	<-s.release
+	return s.Store.CreateLobby(ctx, rec)
+}
+
+func TestLobby_NewLobbyDoesNotWaitOnTheStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := slowStore{Store: store.NewMemory(), release: make(chan struct{})}
+
+	created := make(chan *Lobby, 1)
+	go func() { created <- NewLobby(ctx, engine.NewEmptyState(), WithStore(st, "SLOWDB")) }()
+	var l *Lobby
+	select {
+	case l = <-created:
+	case <-time.After(time.Second):
+		t.Fatalf("NewLobby blocked on the store")
+	}
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+
+	// Once the store catches up it has the lobby and everything logged since
+	close(st.release)
+	l.Inbox() <- Shutdown{}
+	<-l.Done()
+	stored, err := st.Events(ctx, "SLOWDB")
+	if err != nil || len(stored) != 3 {
+		t.Fatalf("want the created event and the ban stored, got %d events (%v)", len(stored), err)
+	}
+}
+
+func TestLobby_StateIsReduceOfLog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	init := engine.NewPreDraftState()
+	init.Rules.PickTimerSec, init.Rules.BanTimerSec = 0, 0 // no clock; the test drives every turn
+	st := store.NewMemory()
+	l := NewLobby(ctx, init, WithStore(st, "PROP01"))
+
+	host := make(chan types.ServerMessage, 1024)
+	l.Inbox() <- Join{ClientID: "host", Outbox: host}
+	l.Inbox() <- ClaimHost{ClientID: "host", Token: l.HostToken()}
+
+	r := rand.New(rand.NewPCG(42, 0))
+	seats := []string{"b1", "b2", "r1", "r2"}
+	for _, cmd := range []engine.Command{
+		{Type: engine.CmdJoinSeat, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdJoinSeat, Team: engine.TeamBlue, SeatID: "b2"},
+		{Type: engine.CmdJoinSeat, Team: engine.TeamRed, SeatID: "r1"},
+		{Type: engine.CmdJoinSeat, Team: engine.TeamRed, SeatID: "r2"},
+		{Type: engine.CmdReady, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdReady, Team: engine.TeamRed, SeatID: "r1"},
+		{Type: engine.CmdStartGame},
+	} {
+		l.Inbox() <- ServerCommand{Cmd: cmd}
+	}
+	for range 300 {
+		seat := seats[r.IntN(len(seats))]
+		team := engine.TeamBlue
+		if seat[0] == 'r' {
+			team = engine.TeamRed
+		}
+		switch r.IntN(8) {
+		case 0:
+			l.Inbox() <- UndoLastAction{ClientID: "host"}
+		case 1:
+			l.Inbox() <- RedoAction{ClientID: "host"}
+		case 2:
+			l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdHoverChampion, Team: team, SeatID: seat, ChampionID: 1 + r.IntN(30)}}
+		case 3:
+			l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: team, ChampionID: 1 + r.IntN(30)}}
+		default:
+			l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdLockPick, Team: team, SeatID: seat, ChampionID: 1 + r.IntN(30)}}
+		}
+	}
+
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	view := recvView(t, reply, time.Second)
+	if view.Log[0].Event.Type != engine.EvtLobbyCreated {
+		t.Fatalf("log must open with LobbyCreated, got %v", view.Log[0].Event.Type)
+	}
+	for i, entry := range view.Log {
+		if entry.Ordinal != i+1 || entry.At.IsZero() {
+			t.Fatalf("entry %d: bad ordinal/timestamp %+v", i, entry)
+		}
+	}
+	if got := engine.Reduce(engine.Events(view.Log)); !reflect.DeepEqual(got, view.State) {
+		t.Fatalf("Reduce(log) != live state.\n got: %#v\nwant: %#v", got, view.State)
+	}
+
+	// The store saw the same log, undos included, once the writes drain
+	l.Inbox() <- Shutdown{}
+	<-l.Done()
+	stored, err := st.Events(ctx, "PROP01")
+	if err != nil {
+		t.Fatalf("stored events: %v", err)
+	}
+	if got := store.LogEntries(stored); !reflect.DeepEqual(got, view.Log) {
+		t.Fatalf("stored log differs from the lobby's: %d vs %d entries", len(got), len(view.Log))
+	}
+}
+
+func TestLobby_RestoreResumesTurnClock(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.BanTimerSec = 20
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first ban went in 5s ago: red should have about 15s left
+	armed := time.Now().Add(-5 * time.Second).UTC()
+	entries := []engine.LogEntry{
+		{Ordinal: 1, Batch: 1, At: armed.Add(-time.Minute), Event: engine.Event{Type: engine.EvtLobbyCreated, Initial: &init}},
+		{Ordinal: 2, Batch: 2, At: armed, Event: engine.Event{Type: engine.EvtChampionBanned, Team: engine.TeamBlue, ChampionID: 1}},
+		{Ordinal: 3, Batch: 2, At: armed, Event: engine.Event{Type: engine.EvtTurnAdvanced}},
+	}
+	l, err := Restore(ctx, slices.Clone(entries))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	snap := recvSnapshot(t, out, 100*time.Millisecond)
+	if snap.State.Cursor != 1 || snap.Timer == nil {
+		t.Fatalf("want cursor 1 with a running clock, got cursor=%d timer=%+v", snap.State.Cursor, snap.Timer)
+	}
+	if left := snap.Timer.RemainingMs; left < 14_000 || left > 15_000 {
+		t.Fatalf("want ~15s left on the clock, got %dms", left)
+	}
+	l.Inbox() <- Shutdown{}
+
+	// Same log, but the server was down past the deadline: the turn times out
+	// straight away and the log carries on from the stored ordinals
+	for i := range entries[1:] {
+		entries[i+1].At = entries[i+1].At.Add(-time.Minute)
+	}
+	l, err = Restore(ctx, entries)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	out = make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+	if snap := recvSnapshot(t, out, 500*time.Millisecond); snap.State.Cursor != 2 {
+		t.Fatalf("want the overdue ban skipped, got cursor=%d", snap.State.Cursor)
+	}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	view := recvView(t, reply, 100*time.Millisecond)
+	if n := len(view.Log); view.Log[n-1].Ordinal != n || view.Log[n-1].Batch != 3 {
+		t.Fatalf("want log to continue at batch 3, got %+v", view.Log[n-1])
+	}
+
+	if _, err := Restore(ctx, nil); err != ErrBadLog {
+		t.Fatalf("want ErrBadLog for an empty log, got %v", err)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_RestoreCarriesOnAboveStoredVersion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := store.NewMemory()
+
+	l := NewLobby(ctx, engine.NewPreDraftState(), WithStore(st, "VERS01"))
+	out := make(chan types.ServerMessage, 32)
+	l.Inbox() <- Join{ClientID: "cap", Outbox: out}
+	welcome := recvSnapshot(t, out, 100*time.Millisecond)
+	l.Inbox() <- ClaimSeat{ClientID: "cap", Team: engine.TeamBlue, SeatID: "b1"}
+	_ = recvType(t, out, "SeatClaimed", 100*time.Millisecond)
+
+	// Dropping and resuming logs nothing, so the versions run well past the
+	// batches
+	idReply := make(chan string, 1)
+	for range 3 {
+		l.Inbox() <- Leave{ClientID: "cap", Outbox: out}
+		out = make(chan types.ServerMessage, 32)
+		l.Inbox() <- Resume{Session: welcome.Session.Token, ClientID: "fresh", Outbox: out, Reply: idReply}
+		<-idReply
+	}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	seen := recvView(t, reply, 100*time.Millisecond).Version
+	l.Inbox() <- Shutdown{}
+	<-l.Done()
+
+	rec, err := st.GetLobby(ctx, "VERS01")
+	if err != nil {
+		t.Fatalf("get lobby: %v", err)
+	}
+	if rec.Version != seen {
+		t.Fatalf("stored version %d, clients saw %d", rec.Version, seen)
+	}
+	events, _ := st.Events(ctx, "VERS01")
+	l, err = Restore(ctx, store.LogEntries(events), WithStore(st, "VERS01"), WithVersion(rec.Version))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	out = make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "cap", Outbox: out}
+	if snap := recvSnapshot(t, out, 100*time.Millisecond); snap.Version <= seen {
+		t.Fatalf("restored at v%d, clients already saw v%d", snap.Version, seen)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+type champSet map[int]bool
+
+func (c champSet) Has(id int) bool { return c[id] }
+func (c champSet) IDs() []int      { return slices.Sorted(maps.Keys(c)) }
+
+func TestLobby_WithChampions_ChecksBansAfterRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	champs := champSet{1: true, 2: true}
+
+	cursorAfter := func(l *Lobby, team engine.Team, ids ...int) int {
+		for _, id := range ids {
+			l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: team, ChampionID: id}}
+		}
+		reply := make(chan View, 1)
+		l.Inbox() <- GetState{Reply: reply}
+		return recvView(t, reply, 100*time.Millisecond).State.Cursor
+	}
+
+	l := NewLobby(ctx, engine.NewEmptyState(), WithChampions(champs))
+	if cur := cursorAfter(l, engine.TeamBlue, 3, 1); cur != 1 {
+		t.Fatalf("want only the ban on the roster to go through, cursor=%d", cur)
+	}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	entries := recvView(t, reply, 100*time.Millisecond).Log
+	l.Inbox() <- Shutdown{}
+
+	// The roster isn't in the log; the restored lobby gets it from its options
+	l, err := Restore(ctx, entries, WithChampions(champs))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if cur := cursorAfter(l, engine.TeamRed, 3, 2); cur != 2 {
+		t.Fatalf("want the restored lobby to keep checking the roster, cursor=%d", cur)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_ServerShutdownNotifiesThenCloses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewPreDraftState())
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+
+	l.Inbox() <- Shutdown{ServerShutdown: true}
+	if notice := recvSnapshot(t, out, 100*time.Millisecond); notice.Type != "ServerShuttingDown" || notice.State == nil {
+		t.Fatalf("want a ServerShuttingDown notice with the final state, got %+v", notice)
+	}
+	select {
+	case <-l.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("lobby goroutine did not exit")
+	}
+	if _, ok := <-out; ok {
+		t.Fatalf("want the outbox closed after the notice")
+	}
+}
+
+func TestLobby_RestoreKeepsSpectatorsOnTheirDelay(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.SpectatorDelaySec = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Blue's ban is old news; red's went in just before the restart
+	now := time.Now().UTC()
+	entries := []engine.LogEntry{
+		{Ordinal: 1, Batch: 1, At: now.Add(-time.Minute), Event: engine.Event{Type: engine.EvtLobbyCreated, Initial: &init}},
+		{Ordinal: 2, Batch: 2, At: now.Add(-30 * time.Second), Event: engine.Event{Type: engine.EvtChampionBanned, Team: engine.TeamBlue, ChampionID: 1}},
+		{Ordinal: 3, Batch: 2, At: now.Add(-30 * time.Second), Event: engine.Event{Type: engine.EvtTurnAdvanced}},
+		{Ordinal: 4, Batch: 3, At: now.Add(-200 * time.Millisecond), Event: engine.Event{Type: engine.EvtChampionBanned, Team: engine.TeamRed, ChampionID: 2}},
+		{Ordinal: 5, Batch: 3, At: now.Add(-200 * time.Millisecond), Event: engine.Event{Type: engine.EvtTurnAdvanced}},
+	}
+	l, err := Restore(ctx, entries)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "spec", Outbox: out, Spectator: true}
+	if snap := recvSnapshot(t, out, 100*time.Millisecond); snap.State.Cursor != 1 {
+		t.Fatalf("spectator saw cursor %d straight after the restart, want 1", snap.State.Cursor)
+	}
+	// Red's ban shows up once its delay is up
+	if snap := recvSnapshot(t, out, time.Second); snap.State.Cursor != 2 || len(snap.State.Bans[engine.TeamRed]) != 1 {
+		t.Fatalf("want red's ban after the delay, got cursor %d", snap.State.Cursor)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_SpectatorsWatchOnDelayAndCannotAct(t *testing.T) {
+	init := engine.NewEmptyState()
+	init.Rules.SpectatorDelaySec = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, init)
+	player := make(chan types.ServerMessage, 8)
+	watcher := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "p1", Outbox: player}
+	l.Inbox() <- Join{ClientID: "s1", Outbox: watcher, Spectator: true}
+	_ = recvSnapshot(t, player, 100*time.Millisecond)
+	if first := recvSnapshot(t, watcher, 100*time.Millisecond); first.Session == nil || first.State.Cursor != 0 {
+		t.Fatalf("want a welcome snapshot for the spectator, got %+v", first)
+	}
+
+	l.Inbox() <- ClaimSeat{ClientID: "s1", Team: engine.TeamBlue, SeatID: "b1"}
+	if msg := recvType(t, watcher, "Unauthorized", 100*time.Millisecond); msg.Error != ErrSpectator.Error() {
+		t.Fatalf("want ErrSpectator for a seat claim, got %q", msg.Error)
+	}
+	l.Inbox() <- FromClient{ClientID: "s1", Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+	if msg := recvType(t, watcher, "Unauthorized", 100*time.Millisecond); msg.Error != ErrSpectator.Error() {
+		t.Fatalf("want ErrSpectator for a command, got %q", msg.Error)
+	}
+
+	// Server-side ban: the player sees it now, the spectator a second later
+	l.Inbox() <- PrimeTimer{}
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+	live := recvSnapshot(t, player, 100*time.Millisecond)
+	if live.State.Cursor != 1 {
+		t.Fatalf("want the player to see the ban, got cursor=%d", live.State.Cursor)
+	}
+	recvNoSnapshot(t, watcher, 700*time.Millisecond)
+
+	// A spectator arriving now still sees the pre-ban board
+	late := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "s2", Outbox: late, Spectator: true}
+	if snap := recvSnapshot(t, late, 100*time.Millisecond); snap.State.Cursor != 0 {
+		t.Fatalf("want a late spectator on the delayed board, got cursor=%d", snap.State.Cursor)
+	}
+
+	held := recvSnapshot(t, watcher, time.Second)
+	if held.State.Cursor != 1 || held.Version != live.Version {
+		t.Fatalf("want the held-back ban snapshot, got cursor=%d version=%d", held.State.Cursor, held.Version)
+	}
+	if held.Timer == nil || !held.Timer.Deadline.Equal(live.Timer.Deadline.Add(time.Second)) {
+		t.Fatalf("want the spectator clock shifted by the delay, got %+v vs live %+v", held.Timer, live.Timer)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_HoversOnlyReachOwnTeam(t *testing.T) {
+	for _, seeAll := range []bool{false, true} {
+		init := engine.NewEmptyState()
+		init.Rules.SpectatorHovers = seeAll
+		ctx, cancel := context.WithCancel(context.Background())
+
+		l := NewLobby(ctx, init)
+		outs := map[string]chan types.ServerMessage{}
+		for _, id := range []string{"blue", "red", "spec", "lurker"} {
+			outs[id] = make(chan types.ServerMessage, 8)
+			l.Inbox() <- Join{ClientID: id, Outbox: outs[id], Spectator: id == "spec"}
+			_ = recvSnapshot(t, outs[id], 100*time.Millisecond)
+		}
+		l.Inbox() <- ClaimSeat{ClientID: "blue", Team: engine.TeamBlue, SeatID: "b1"}
+		_ = recvType(t, outs["blue"], "SeatClaimed", 100*time.Millisecond)
+		l.Inbox() <- ClaimSeat{ClientID: "red", Team: engine.TeamRed, SeatID: "r1"}
+		_ = recvType(t, outs["red"], "SeatClaimed", 100*time.Millisecond)
+
+		l.Inbox() <- FromClient{ClientID: "blue", Cmd: engine.Command{Type: engine.CmdHoverChampion, Team: engine.TeamBlue, ChampionID: 7}}
+		want := map[string]bool{"blue": true, "red": false, "spec": seeAll, "lurker": false}
+		for id, sees := range want {
+			snap := recvSnapshot(t, outs[id], 100*time.Millisecond)
+			if got := snap.State.Hover["b1"] == 7; got != sees {
+				t.Fatalf("spectatorHovers=%v: %s sees blue's hover=%v, want %v", seeAll, id, got, sees)
+			}
+		}
+
+		// The live state still has it (timeouts lock hovers)
+		reply := make(chan View, 1)
+		l.Inbox() <- GetState{Reply: reply}
+		if view := recvView(t, reply, 100*time.Millisecond); view.State.Hover["b1"] != 7 {
+			t.Fatalf("filtering leaked into lobby state: %v", view.State.Hover)
+		}
+		l.Inbox() <- Shutdown{}
+		cancel()
+	}
+}
+
+func TestLobby_PatchesReplayToServerState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	init := engine.NewPreDraftState()
+	init.Rules.PickTimerSec, init.Rules.BanTimerSec = 0, 0
+	init.Rules.SpectatorHovers = true
+	l := NewLobby(ctx, init)
+
+	// A spectator sees every hover, a seatless player none; both get patches
+	collect := func(id string, spectator bool) <-chan []types.ServerMessage {
+		out := make(chan types.ServerMessage, 64)
+		l.Inbox() <- Join{ClientID: id, Outbox: out, Spectator: spectator, Patches: true}
+		done := make(chan []types.ServerMessage, 1)
+		go func() {
+			var got []types.ServerMessage
+			for msg := range out {
+				got = append(got, msg)
+			}
+			done <- got
+		}()
+		return done
+	}
+	spectator, lurker := collect("spec", true), collect("lurker", false)
+
+	host := make(chan types.ServerMessage, 1024)
+	l.Inbox() <- Join{ClientID: "host", Outbox: host}
+	l.Inbox() <- ClaimHost{ClientID: "host", Token: l.HostToken()}
+
+	r := rand.New(rand.NewPCG(7, 0))
+	for _, cmd := range []engine.Command{
+		{Type: engine.CmdJoinSeat, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdJoinSeat, Team: engine.TeamRed, SeatID: "r1"},
+		{Type: engine.CmdReady, Team: engine.TeamBlue, SeatID: "b1"},
+		{Type: engine.CmdReady, Team: engine.TeamRed, SeatID: "r1"},
+		{Type: engine.CmdStartGame},
+	} {
+		l.Inbox() <- ServerCommand{Cmd: cmd}
+	}
+	for range 200 {
+		team, seat := engine.TeamBlue, "b1"
+		if r.IntN(2) == 0 {
+			team, seat = engine.TeamRed, "r1"
+		}
+		switch r.IntN(6) {
+		case 0:
+			l.Inbox() <- UndoLastAction{ClientID: "host"}
+		case 1:
+			l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdHoverChampion, Team: team, SeatID: seat, ChampionID: 1 + r.IntN(30)}}
+		case 2:
+			l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: team, ChampionID: 1 + r.IntN(30)}}
+		default:
+			l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdLockPick, Team: team, SeatID: seat, ChampionID: 1 + r.IntN(30)}}
+		}
+	}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	view := recvView(t, reply, time.Second)
+	l.Inbox() <- Shutdown{}
+
+	normalize := func(s engine.State) engine.State {
+		out, err := ApplyPatch(s, nil) // JSON round trip, like a client's copy
+		if err != nil {
+			t.Fatalf("round trip: %v", err)
+		}
+		return out
+	}
+	hidden := view.State.Clone()
+	hidden.Hover = map[string]int{}
+	for name, tc := range map[string]struct {
+		msgs <-chan []types.ServerMessage
+		want engine.State
+	}{"spectator": {spectator, view.State}, "lurker": {lurker, hidden}} {
+		msgs := <-tc.msgs
+		if len(msgs) < 2 || msgs[0].Type != "StateSnapshot" {
+			t.Fatalf("%s: want a snapshot then patches, got %d messages", name, len(msgs))
+		}
+		state, version := *msgs[0].State, msgs[0].Version
+		for i, msg := range msgs[1:] {
+			if msg.Type != "StatePatch" || msg.State != nil {
+				t.Fatalf("%s: message %d is %s, want a bare StatePatch", name, i, msg.Type)
+			}
+			if msg.BaseVersion != version {
+				t.Fatalf("%s: patch %d on version %d, client has %d", name, i, msg.BaseVersion, version)
+			}
+			if _, ok := msg.Patch["Rules"]; ok {
+				t.Fatalf("%s: patch %d resent unchanged Rules", name, i)
+			}
+			var err error
+			if state, err = ApplyPatch(state, msg.Patch); err != nil {
+				t.Fatalf("%s: apply patch %d: %v", name, i, err)
+			}
+			version = msg.Version
+		}
+		if got, want := normalize(state), normalize(tc.want); version != view.Version || !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: patched state (v%d) != server state (v%d).\n got: %#v\nwant: %#v", name, version, view.Version, got, want)
+		}
+	}
+}
+
+func TestLobby_PresenceChangesBumpTheVersion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewPreDraftState(), WithReconnectGrace(time.Minute))
+	watcher := make(chan types.ServerMessage, 16)
+	l.Inbox() <- Join{ClientID: "watch", Outbox: watcher, Patches: true}
+	first := make(chan types.ServerMessage, 16)
+	l.Inbox() <- Join{ClientID: "cap", Outbox: first}
+	welcome := recvSnapshot(t, first, 100*time.Millisecond)
+	l.Inbox() <- ClaimSeat{ClientID: "cap", Team: engine.TeamBlue, SeatID: "b1"}
+	_ = recvType(t, first, "SeatClaimed", 100*time.Millisecond)
+
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	version := recvView(t, reply, 100*time.Millisecond).Version
+	for msg := recvSnapshot(t, watcher, 100*time.Millisecond); msg.Version != version; {
+		msg = recvSnapshot(t, watcher, 100*time.Millisecond)
+	}
+
+	// Disconnect then resume: no command runs, but each is a new version the
+	// watcher's patches chain through
+	l.Inbox() <- Leave{ClientID: "cap", Outbox: first}
+	idReply := make(chan string, 1)
+	l.Inbox() <- Resume{Session: welcome.Session.Token, ClientID: "fresh", Outbox: make(chan types.ServerMessage, 16), Reply: idReply}
+	<-idReply
+	for _, want := range []string{types.SeatReconnecting, types.SeatConnected} {
+		patch := recvSnapshot(t, watcher, 100*time.Millisecond)
+		if patch.Type != "StatePatch" || patch.BaseVersion != version || patch.Version != version+1 {
+			t.Fatalf("want a patch v%d→v%d, got %s v%d→v%d", version, version+1, patch.Type, patch.BaseVersion, patch.Version)
+		}
+		if patch.Presence[0].Status != want {
+			t.Fatalf("presence = %+v, want b1 %s", patch.Presence, want)
+		}
+		version = patch.Version
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_RequestSnapshotAfterGap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewEmptyState())
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out, Patches: true}
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+	patch := recvSnapshot(t, out, 100*time.Millisecond)
+	if patch.Type != "StatePatch" || string(patch.Patch["Cursor"]) != "1" {
+		t.Fatalf("want a patch moving the cursor, got %+v", patch)
+	}
+
+	l.Inbox() <- RequestSnapshot{ClientID: "c1"}
+	full := recvSnapshot(t, out, 100*time.Millisecond)
+	if full.Type != "StateSnapshot" || full.Version != patch.Version || full.State.Cursor != 1 {
+		t.Fatalf("want a full snapshot at v%d, got %+v", patch.Version, full)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_SyncReplaysPatchesSinceVersion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewEmptyState())
+	out := make(chan types.ServerMessage, 8)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out, Patches: true}
+	welcome := recvSnapshot(t, out, 100*time.Millisecond)
+
+	for i, team := range []engine.Team{engine.TeamBlue, engine.TeamRed, engine.TeamBlue} {
+		l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: team, ChampionID: 10 + i}}
+	}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	view := recvView(t, reply, 100*time.Millisecond)
+
+	// The client applies the first patch and throws the next two away, as if
+	// they never arrived
+	first := recvSnapshot(t, out, 100*time.Millisecond)
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+	_ = recvSnapshot(t, out, 100*time.Millisecond)
+	state, err := ApplyPatch(*welcome.State, first.Patch)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	l.Inbox() <- Sync{ClientID: "c1", SinceVersion: first.Version}
+	version := first.Version
+	for version != view.Version {
+		patch := recvSnapshot(t, out, 100*time.Millisecond)
+		if patch.Type != "StatePatch" || patch.BaseVersion != version {
+			t.Fatalf("want a patch on v%d, got %s on v%d", version, patch.Type, patch.BaseVersion)
+		}
+		if state, err = ApplyPatch(state, patch.Patch); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+		version = patch.Version
+	}
+	if !reflect.DeepEqual(state.Bans, view.State.Bans) || state.Cursor != view.State.Cursor {
+		t.Fatalf("synced state differs: bans=%v cursor=%d, want %v %d", state.Bans, state.Cursor, view.State.Bans, view.State.Cursor)
+	}
+
+	// Too far back for the history: a full snapshot instead
+	l.Inbox() <- Sync{ClientID: "c1", SinceVersion: -5}
+	if full := recvSnapshot(t, out, 100*time.Millisecond); full.Type != "StateSnapshot" || full.Reason != "Sync" {
+		t.Fatalf("want a full Sync snapshot, got %s (%q)", full.Type, full.Reason)
+	}
+	l.Inbox() <- Shutdown{}
+}
+func TestLobby_SlowClientGetsOneCoalescedSnapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewEmptyState(), WithBackpressure(40*time.Millisecond, 100))
+	out := make(chan types.ServerMessage, 1)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out, Patches: true}
+
+	// Welcome fills the outbox; all three patches coalesce
+	for i, team := range []engine.Team{engine.TeamBlue, engine.TeamRed, engine.TeamBlue} {
+		l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: team, ChampionID: 10 + i}}
+	}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	view := recvView(t, reply, 100*time.Millisecond)
+	if view.NumClients != 1 || view.Lag["c1"] <= 0 {
+		t.Fatalf("want the slow client kept and lagging, got clients=%d lag=%v", view.NumClients, view.Lag)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	_ = recvSnapshot(t, out, 100*time.Millisecond) // welcome
+	caught := recvSnapshot(t, out, 200*time.Millisecond)
+	if caught.Type != "StateSnapshot" || caught.Reason != "Coalesced" || caught.Version != view.Version || caught.State.Cursor != 3 {
+		t.Fatalf("want one coalesced snapshot at v%d, got %s %q v%d", view.Version, caught.Type, caught.Reason, caught.Version)
+	}
+	recvNoSnapshot(t, out, 50*time.Millisecond)
+
+	// Caught up: patches again, on top of the coalesced snapshot
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamRed, ChampionID: 20}}
+	if patch := recvSnapshot(t, out, 100*time.Millisecond); patch.Type != "StatePatch" || patch.BaseVersion != caught.Version {
+		t.Fatalf("want a patch on v%d, got %s on v%d", caught.Version, patch.Type, patch.BaseVersion)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_SlowClientStillGetsItsSeatToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewPreDraftState(), WithBackpressure(20*time.Millisecond, 100))
+	out := make(chan types.ServerMessage, 1)
+	l.Inbox() <- Join{ClientID: "cap", Outbox: out}
+
+	// Welcome fills the outbox: the join's snapshot coalesces and the token
+	// queues behind it
+	l.Inbox() <- ClaimSeat{ClientID: "cap", Team: engine.TeamBlue, SeatID: "b1"}
+	reply := make(chan View, 1)
+	l.Inbox() <- GetState{Reply: reply}
+	_ = recvView(t, reply, 100*time.Millisecond)
+
+	if welcome := recvSnapshot(t, out, 100*time.Millisecond); welcome.Type != "StateSnapshot" {
+		t.Fatalf("want the welcome first, got %s", welcome.Type)
+	}
+	claimed := recvSnapshot(t, out, 200*time.Millisecond)
+	if claimed.Type != "SeatClaimed" || claimed.Seat == nil || claimed.Seat.Token == "" {
+		t.Fatalf("want SeatClaimed with a token, got %+v", claimed)
+	}
+	caught := recvSnapshot(t, out, 200*time.Millisecond)
+	if caught.Reason != "Coalesced" || len(caught.State.Seats[engine.TeamBlue]) != 1 {
+		t.Fatalf("want a coalesced snapshot with b1 seated, got %s %q", caught.Type, caught.Reason)
+	}
+	l.Inbox() <- Shutdown{}
+}
+
+func TestLobby_LaggingClientEvictedAfterMissedDeadlines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewLobby(ctx, engine.NewEmptyState(), WithBackpressure(20*time.Millisecond, 3))
+	out := make(chan types.ServerMessage, 1)
+	l.Inbox() <- Join{ClientID: "c1", Outbox: out}
+	l.Inbox() <- ServerCommand{Cmd: engine.Command{Type: engine.CmdBanChampion, Team: engine.TeamBlue, ChampionID: 1}}
+
+	// Never reads; kept through two missed deadlines, gone after the third
+	reply := make(chan View, 1)
+	time.Sleep(30 * time.Millisecond)
+	l.Inbox() <- GetState{Reply: reply}
+	if view := recvView(t, reply, 100*time.Millisecond); view.NumClients != 1 {
+		t.Fatalf("evicted too early")
+	}
+	time.Sleep(100 * time.Millisecond)
+	l.Inbox() <- GetState{Reply: reply}
+	if view := recvView(t, reply, 100*time.Millisecond); view.NumClients != 0 {
+		t.Fatalf("want the lagging client evicted, still %d clients", view.NumClients)
+	}
+
+	if notice := recvSnapshot(t, out, 100*time.Millisecond); notice.Type != "Lagging" {
+		t.Fatalf("want a Lagging notice before the disconnect, got %s", notice.Type)
+	}
+	if _, ok := <-out; ok {
+		t.Fatalf("want the outbox closed after the notice")
+	}
+	l.Inbox() <- Shutdown{}
+}
@@ -0,0 +1,96 @@
+package lobby
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+// Clients that join with Patches set get one full StateSnapshot and, from
+// then on, a StatePatch per broadcast: the top-level State fields that
+// changed (a whole Picks map, the new Cursor, ...) plus the usual timer and
+// presence. A patch applies on top of BaseVersion; a client holding any other
+// version has missed one and sends RequestSnapshot to start over.
+
+// RequestSnapshot asks for a full StateSnapshot, e.g. after a version gap.
+type RequestSnapshot struct{ ClientID string }
+
+func (RequestSnapshot) isLobbyMsg() {}
+
+func (l *Lobby) sendSnapshot(clientID string) {
+	if c, ok := l.clients[clientID]; ok {
+		l.sendTo(clientID, l.snapshotFor(c))
+	}
+}
+
+// toPatch turns the snapshot that follows prev into a patch on top of it.
+// Both must already be cut down for the same audience.
+func toPatch(prev, next types.ServerMessage) types.ServerMessage {
+	patch, err := diffState(*prev.State, *next.State)
+	if err != nil {
+		log.Printf("patch: %v; sending a full snapshot", err)
+		return next
+	}
+	next.Type = "StatePatch"
+	next.BaseVersion = prev.Version
+	next.Patch = patch
+	next.State = nil
+	return next
+}
+
+// diffState returns next's JSON fields that differ from prev's. A field
+// missing from next comes back as null.
+func diffState(prev, next engine.State) (map[string]json.RawMessage, error) {
+	before, err := stateFields(prev)
+	if err != nil {
+		return nil, err
+	}
+	after, err := stateFields(next)
+	if err != nil {
+		return nil, err
+	}
+	patch := make(map[string]json.RawMessage)
+	for key, val := range after {
+		if !bytes.Equal(before[key], val) {
+			patch[key] = val
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			patch[key] = json.RawMessage("null")
+		}
+	}
+	return patch, nil
+}
+
+// ApplyPatch is what a client does with a StatePatch: replace each listed
+// top-level field of s.
+func ApplyPatch(s engine.State, patch map[string]json.RawMessage) (engine.State, error) {
+	fields, err := stateFields(s)
+	if err != nil {
+		return engine.State{}, err
+	}
+	for key, val := range patch {
+		fields[key] = val
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return engine.State{}, err
+	}
+	var out engine.State
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func stateFields(s engine.State) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	return fields, err
+}
@@ -0,0 +1,190 @@
+package lobby
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/store"
+)
+
+// Store writes run on a goroutine of their own so a slow disk never holds up
+// the turn clock or broadcasts, or the hub creating the lobby. The lobby
+// queues them in order and moves on; the writer takes whatever has piled up
+// since its last write, so a burst of hovers lands as one append. Shutdown
+// waits for the queue to drain.
+
+// storeWrite is one queued change: store the lobby itself (create, first
+// write only), drop everything in its log from truncateFrom on (undo), append
+// events, record the state version just broadcast, then mark it closed
+// (close, last write only), each if set.
+type storeWrite struct {
+	create       *store.Lobby
+	truncateFrom int
+	events       []store.Event
+	version      int
+	close        bool
+}
+
+type writer struct {
+	store store.Store
+	code  string
+
+	mu     sync.Mutex
+	queue  []storeWrite
+	closed bool
+	wake   chan struct{}
+	done   chan struct{}
+
+	failed bool // the lobby couldn't be stored; later writes are dropped (writer goroutine only)
+}
+
+// create starts the writer with storing the new lobby and its opening
+// entries as its first write. If the lobby can't be stored (e.g. the code is
+// taken by an old lobby) it runs unpersisted rather than failing every append.
+func (l *Lobby) create(initial engine.State) {
+	rec := store.LobbyFromState(l.code, initial)
+	rec.Secret = l.secret
+	l.startWriter()
+	l.writer.enqueue(storeWrite{create: &rec, events: l.storeEvents(l.log)})
+}
+
+func (l *Lobby) startWriter() {
+	l.writer = &writer{
+		store: l.store,
+		code:  l.code,
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	// Writes queued before a shutdown still go out after it
+	go l.writer.run(context.WithoutCancel(l.ctx))
+}
+
+// persist queues freshly logged entries for the store, if there is one.
+func (l *Lobby) persist(entries []engine.LogEntry) {
+	if l.writer == nil || len(entries) == 0 {
+		return
+	}
+	l.writer.enqueue(storeWrite{events: l.storeEvents(entries)})
+}
+
+// persistRewrite queues replacing the stored log from ordinal from on with
+// entries.
+func (l *Lobby) persistRewrite(from int, entries []engine.LogEntry) {
+	if l.writer == nil {
+		return
+	}
+	l.writer.enqueue(storeWrite{truncateFrom: from, events: l.storeEvents(entries)})
+}
+
+// persistVersion queues recording the version just broadcast. Only the
+// newest one queued gets written.
+func (l *Lobby) persistVersion() {
+	if l.writer == nil {
+		return
+	}
+	l.writer.enqueue(storeWrite{version: l.version})
+}
+
+// persistClose queues marking the lobby closed in the store, so a restart
+// doesn't bring it back.
+func (l *Lobby) persistClose() {
+	if l.writer == nil {
+		return
+	}
+	l.writer.enqueue(storeWrite{close: true})
+}
+
+func (l *Lobby) storeEvents(entries []engine.LogEntry) []store.Event {
+	recs := make([]store.Event, len(entries))
+	for i, entry := range entries {
+		recs[i] = store.Event{LogEntry: entry, Game: l.state.Series.GameIndex}
+	}
+	return recs
+}
+
+func (w *writer) enqueue(sw storeWrite) {
+	w.mu.Lock()
+	w.queue = append(w.queue, sw)
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default: // already due to run
+	}
+}
+
+// close writes out whatever is still queued and stops the writer.
+func (w *writer) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	<-w.done
+}
+
+func (w *writer) run(ctx context.Context) {
+	defer close(w.done)
+	for range w.wake {
+		w.mu.Lock()
+		queued, closed := w.queue, w.closed
+		w.queue = nil
+		w.mu.Unlock()
+
+		w.write(ctx, queued)
+		if closed {
+			return
+		}
+	}
+}
+
+// write applies queued changes in order, merging back-to-back appends and
+// writing only the newest version.
+func (w *writer) write(ctx context.Context, queued []storeWrite) {
+	var pending []store.Event
+	version := 0
+	flush := func() {
+		if len(pending) > 0 {
+			if err := w.store.AppendEvents(ctx, w.code, pending); err != nil {
+				log.Printf("store: append %d events to %s: %v", len(pending), w.code, err)
+			}
+			pending = nil
+		}
+		if version > 0 {
+			if err := w.store.SetVersion(ctx, w.code, version); err != nil {
+				log.Printf("store: set %s version %d: %v", w.code, version, err)
+			}
+			version = 0
+		}
+	}
+	for _, sw := range queued {
+		if w.failed {
+			return
+		}
+		if sw.create != nil {
+			if err := w.store.CreateLobby(ctx, *sw.create); err != nil {
+				log.Printf("store: create lobby %s: %v; not persisting it", w.code, err)
+				w.failed = true
+				return
+			}
+		}
+		if sw.truncateFrom > 0 {
+			flush()
+			if err := w.store.TruncateEvents(ctx, w.code, sw.truncateFrom); err != nil {
+				log.Printf("store: truncate %s at %d: %v", w.code, sw.truncateFrom, err)
+			}
+		}
+		pending = append(pending, sw.events...)
+		version = max(version, sw.version)
+		if sw.close {
+			flush()
+			if err := w.store.CloseLobby(ctx, w.code); err != nil {
+				log.Printf("store: close %s: %v", w.code, err)
+			}
+		}
+	}
+	flush()
+}
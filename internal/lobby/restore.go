@@ -0,0 +1,85 @@
+package lobby
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+)
+
+var ErrBadLog = errors.New("log does not start with LobbyCreated")
+
+// Restore brings a lobby back from its event log, e.g. after a server
+// restart. State is Reduce(log); nothing is re-persisted, and new events
+// carry on from the last stored ordinal. Clients reconnect as fresh joins and
+// take their seats back with their seat tokens (pass WithSecret so those
+// still verify). Pass WithVersion too, so versions carry on above any a
+// client has already seen.
+func Restore(parent context.Context, entries []engine.LogEntry, opts ...Option) (*Lobby, error) {
+	if len(entries) == 0 || entries[0].Event.Type != engine.EvtLobbyCreated || entries[0].Event.Initial == nil {
+		return nil, ErrBadLog
+	}
+	l := newLobby(parent, opts)
+	if l.store != nil {
+		l.startWriter()
+	}
+	l.log = slices.Clone(entries)
+	if l.champions != nil {
+		initial := *l.log[0].Event.Initial
+		initial.Rules.Champions = l.champions
+		l.log[0].Event.Initial = &initial
+	}
+	l.batch = entries[len(entries)-1].Batch
+	// Every logged batch was broadcast, so the batch is a floor for a store
+	// that predates versions. The restored snapshot is new to everyone.
+	l.version = max(l.version, l.batch) + 1
+	l.persistVersion()
+	l.state = engine.Reduce(engine.Events(l.log))
+	now := time.Now()
+	l.restoreTimer(now)
+	l.remember(l.snapshot(""))
+	l.restoreSpectators(now)
+	log.Printf("lobby %s: restored %d events, cursor=%d phase=%s", l.code, len(l.log), l.state.Cursor, l.state.Phase)
+	go l.loop()
+	return l, nil
+}
+
+// restoreTimer re-arms the turn clock with whatever was left on it when the
+// server went down. The clock was last armed by the newest batch that
+// advanced the turn, started a timebank or resumed the draft, so the
+// deadline is that entry's time plus the turn's length. A deadline that has
+// already passed fires straight away and the turn times out as usual.
+//
+// An undo re-arms a full clock without logging anything; after a restart
+// that turn is timed from the action before the undone one instead.
+func (l *Lobby) restoreTimer(now time.Time) {
+	if l.state.Paused || l.state.PreDraft {
+		return
+	}
+	dur, ok := l.turnDuration()
+	if !ok {
+		return
+	}
+	for i := len(l.log) - 1; i >= 0; i-- {
+		entry := l.log[i]
+		switch entry.Event.Type {
+		case engine.EvtTurnAdvanced, engine.EvtTimerStarted, engine.EvtNextGameStarted, engine.EvtTradePhaseStarted:
+		case engine.EvtTimeBankStarted:
+			l.bankStart = entry.At
+		case engine.EvtDraftResumed:
+			left := time.Duration(entry.Event.Millis) * time.Millisecond
+			if l.state.TimeBankActive {
+				// Same as the live resume: pause time doesn't come out of the reserve
+				l.bankStart = entry.At.Add(-(dur - left))
+			}
+			dur = left
+		default:
+			continue
+		}
+		l.armTimer(max(entry.At.Add(dur).Sub(now), time.Millisecond))
+		return
+	}
+}
@@ -0,0 +1,48 @@
+package lobby
+
+import (
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+// A captain's StartNextGame only reports who won the game just drafted. It
+// counts once the other captain reports the same winner, so neither side can
+// hand itself the game; the host's report counts on its own.
+
+// resultReport is what one captain reported, as of log batch `batch`. Any
+// command logged since makes it stale.
+type resultReport struct {
+	batch  int
+	winner engine.Team
+}
+
+// reportResult records a StartNextGame from clientID and reports whether it
+// can go to the engine: it's the host's, the other captain already named the
+// same winner, or the engine would turn it down anyway.
+func (l *Lobby) reportResult(clientID string, cmd engine.Command) bool {
+	if l.isHost(clientID) {
+		return true
+	}
+	if _, _, err := engine.Apply(l.state.Clone(), cmd); err != nil {
+		return true // let it fail the usual way
+	}
+
+	c := l.clients[clientID]
+	other := engine.TeamRed
+	if c.team == engine.TeamRed {
+		other = engine.TeamBlue
+	}
+	if theirs, ok := l.results[other]; ok && theirs.batch == l.batch && theirs.winner == cmd.Team {
+		clear(l.results)
+		return true
+	}
+	l.results[c.team] = resultReport{batch: l.batch, winner: cmd.Team}
+
+	// Both captains hear about it; the other one confirms by sending the same
+	for id, o := range l.clients {
+		if l.isCaptain(o) {
+			l.sendTo(id, types.ServerMessage{Type: "ResultReported", Winner: string(cmd.Team)})
+		}
+	}
+	return false
+}
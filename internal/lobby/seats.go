@@ -0,0 +1,211 @@
+package lobby
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+var ErrNoSeat = errors.New("claim a seat first")
+var ErrNotYourSeat = errors.New("command does not match your seat")
+var ErrBadSeatToken = errors.New("invalid seat token")
+var ErrSeatClaimed = errors.New("seat already claimed; present its token to reclaim it")
+var ErrAlreadySeated = errors.New("connection already holds a seat")
+var ErrNotHostOrCaptain = errors.New("only the host or a captain can do that")
+
+// ClaimSeat binds a connection to a team and seat. A fresh seat is joined on
+// the engine side (pre-draft only) and a signed token comes back in a
+// SeatClaimed message; presenting that token later reclaims the same seat.
+type ClaimSeat struct {
+	ClientID string
+	Team     engine.Team
+	SeatID   string
+	Name     string
+	Role     string
+	Token    string
+}
+
+func (ClaimSeat) isLobbyMsg() {}
+
+func (l *Lobby) claimSeat(msg ClaimSeat) {
+	c, ok := l.clients[msg.ClientID]
+	if !ok {
+		return
+	}
+	deny := func(err error) {
+		l.sendTo(msg.ClientID, types.ServerMessage{Type: "Unauthorized", Error: err.Error()})
+	}
+	if c.spectator {
+		deny(ErrSpectator)
+		return
+	}
+	if c.seatID != "" && (c.team != msg.Team || c.seatID != msg.SeatID) {
+		deny(ErrAlreadySeated)
+		return
+	}
+
+	switch {
+	case c.seatID != "":
+		// Already holds this exact seat; just hand the token back
+
+	case msg.Token != "":
+		team, seatID, ok := l.verifySeatToken(msg.Token)
+		if !ok || team != msg.Team || seatID != msg.SeatID {
+			deny(ErrBadSeatToken)
+			return
+		}
+		// A valid token wins over whoever held the seat before
+		for id, other := range l.clients {
+			if other != c && other.team == team && other.seatID == seatID {
+				other.team, other.seatID = "", ""
+				if other.out == nil {
+					l.forget(id) // was only being held for a reconnect
+				}
+			}
+		}
+		defer l.broadcastState() // presence changed
+
+	case l.seatHeld(msg.Team, msg.SeatID), l.seatExists(msg.Team, msg.SeatID):
+		deny(ErrSeatClaimed)
+		return
+
+	case l.state.PreDraft:
+		// Bind first so the join snapshot already shows the seat as connected
+		c.team, c.seatID = msg.Team, msg.SeatID
+		cmd := engine.Command{Type: engine.CmdJoinSeat, Team: msg.Team, SeatID: msg.SeatID, Name: msg.Name, Role: msg.Role}
+		if err := l.handleCommand(msg.ClientID, cmd); err != nil {
+			c.team, c.seatID = "", ""
+			return
+		}
+
+	case len(l.state.Seats[msg.Team]) > 0:
+		// Roster is locked once the draft starts
+		deny(engine.ErrDraftStarted)
+		return
+	}
+	// Otherwise the draft has no roster for this team (e.g. started without a
+	// pre-draft); the claim just pins the connection to the side.
+
+	c.team, c.seatID = msg.Team, msg.SeatID
+	l.sendTo(msg.ClientID, types.ServerMessage{
+		Type: "SeatClaimed",
+		Seat: &types.SeatClaim{Team: string(msg.Team), SeatID: msg.SeatID, Token: l.signSeatToken(msg.Team, msg.SeatID)},
+	})
+}
+
+// seatHeld reports whether a client (connected or held for a reconnect)
+// already sits in the seat. Seats on the engine's roster are covered by
+// seatExists; this also catches sides that have no roster.
+func (l *Lobby) seatHeld(team engine.Team, seatID string) bool {
+	for _, c := range l.clients {
+		if c.team == team && c.seatID == seatID {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Lobby) seatExists(team engine.Team, seatID string) bool {
+	for _, seat := range l.state.Seats[team] {
+		if seat.ID == seatID {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize checks a command against the sender's claimed seat and fills in
+// the seat ID so clients can't act for someone else. The server's own
+// commands don't come through here; see ServerCommand.
+func (l *Lobby) authorize(clientID string, cmd *engine.Command) error {
+	c, ok := l.clients[clientID]
+	if ok && c.spectator {
+		return ErrSpectator
+	}
+	switch cmd.Type {
+	case engine.CmdPauseDraft, engine.CmdResumeDraft:
+		// Host only, seated or not
+		if !ok || !c.host {
+			return ErrNotHost
+		}
+		return nil
+	case engine.CmdStartGame, engine.CmdStartNextGame:
+		// StartNextGame's Team is the reported winner, not the sender's side,
+		// so neither is up to just any seat (and a captain's report needs the
+		// other captain to agree; see results.go)
+		if !ok || !c.host && !l.isCaptain(c) {
+			return ErrNotHostOrCaptain
+		}
+		return nil
+	}
+	if !ok || c.seatID == "" {
+		return ErrNoSeat
+	}
+
+	if cmd.Type == engine.CmdJoinSeat {
+		// Seats are taken via ClaimSeat so the token gets issued
+		return ErrNotYourSeat
+	}
+	if cmd.Team != c.team || (cmd.SeatID != "" && cmd.SeatID != c.seatID) {
+		return ErrNotYourSeat
+	}
+	cmd.SeatID = c.seatID
+	return nil
+}
+
+func (l *Lobby) isCaptain(c *client) bool {
+	if c.seatID == "" {
+		return false
+	}
+	captain, ok := engine.Captain(l.state, c.team)
+	return ok && captain.ID == c.seatID
+}
+
+// ---- Seat tokens ----
+// token = base64(team "/" seat) "." base64(hmac-sha256(secret, team "/" seat))
+
+func newSecret() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (l *Lobby) signSeatToken(team engine.Team, seatID string) string {
+	payload := string(team) + "/" + seatID
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(l.mac(payload))
+}
+
+func (l *Lobby) verifySeatToken(token string) (engine.Team, string, bool) {
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return "", "", false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil || !hmac.Equal(sig, l.mac(string(payload))) {
+		return "", "", false
+	}
+	team, seatID, ok := strings.Cut(string(payload), "/")
+	if !ok {
+		return "", "", false
+	}
+	return engine.Team(team), seatID, true
+}
+
+func (l *Lobby) mac(payload string) []byte {
+	m := hmac.New(sha256.New, l.secret)
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
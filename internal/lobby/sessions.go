@@ -0,0 +1,168 @@
+package lobby
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+// Resume rebinds a new socket to the client that owns Session, keeping its
+// client ID and seat. Unknown or expired sessions fall back to a fresh join
+// under ClientID. Reply gets the client ID the socket should use from now on.
+type Resume struct {
+	Session  string
+	ClientID string
+	Outbox   chan types.ServerMessage
+	Reply    chan string
+
+	// How to join fresh; a resumed client keeps its own
+	Spectator bool
+	Patches   bool
+}
+
+func (Resume) isLobbyMsg() {}
+
+// GraceExpired fires when a disconnected seated client didn't come back in time.
+type GraceExpired struct {
+	ClientID string
+	Gen      int
+}
+
+func (GraceExpired) isLobbyMsg() {}
+
+// join registers a new client with a fresh session and sends the first
+// snapshot, which carries the session token for later resumes.
+// joinAs is how a new client wants to be served.
+type joinAs struct{ spectator, patches bool }
+
+func (l *Lobby) join(clientID string, out chan types.ServerMessage, as joinAs, resumed bool) {
+	c := &client{out: out, session: newSessionToken(), spectator: as.spectator, patches: as.patches}
+	l.clients[clientID] = c
+	l.sessions[c.session] = clientID
+	l.sendWelcome(clientID, resumed)
+}
+
+func (l *Lobby) sendWelcome(clientID string, resumed bool) {
+	c := l.clients[clientID]
+	msg := l.snapshotFor(c)
+	msg.Session = &types.Session{ClientID: clientID, Token: c.session, Resumed: resumed}
+	l.sendTo(clientID, msg)
+}
+
+// snapshotFor is the full snapshot c may see right now.
+func (l *Lobby) snapshotFor(c *client) types.ServerMessage {
+	if c.spectator {
+		return l.spectatorSnapshot()
+	}
+	return l.visibleTo(c, l.snapshot(""))
+}
+
+func (l *Lobby) resume(msg Resume) {
+	clientID, ok := l.sessions[msg.Session]
+	c := l.clients[clientID]
+	if !ok || c == nil {
+		l.join(msg.ClientID, msg.Outbox, joinAs{spectator: msg.Spectator, patches: msg.Patches}, false)
+		msg.Reply <- msg.ClientID
+		return
+	}
+
+	// Same identity on a new socket; retire the old socket if it's still open
+	if c.out != nil {
+		close(c.out)
+	}
+	if c.graceTimer != nil {
+		c.graceTimer.Stop()
+		c.graceTimer = nil
+	}
+	c.graceGen++
+	c.out = msg.Outbox
+	c.behindSince, c.missed, c.queued = time.Time{}, 0, nil // the welcome is a full snapshot
+	msg.Reply <- clientID
+	log.Printf("session: resumed client=%s seat=%s/%s", clientID, c.team, c.seatID)
+
+	l.sendWelcome(clientID, true)
+	if c.seatID != "" {
+		l.broadcastState() // seat goes back from "reconnecting" to "connected"
+	}
+}
+
+// disconnect closes a client's outbox. Seated clients are kept for the grace
+// period so the seat reads "reconnecting"; anyone else is forgotten.
+func (l *Lobby) disconnect(clientID string) {
+	c, ok := l.clients[clientID]
+	if !ok || c.out == nil {
+		return
+	}
+	close(c.out) // let WS writer goroutine exit
+	c.out = nil
+
+	if c.seatID == "" || l.grace <= 0 {
+		l.forget(clientID)
+		if c.seatID != "" {
+			l.broadcastState()
+		}
+		return
+	}
+
+	c.graceGen++
+	gen := c.graceGen
+	c.graceTimer = time.AfterFunc(l.grace, func() {
+		select {
+		case l.inbox <- GraceExpired{ClientID: clientID, Gen: gen}:
+		case <-l.ctx.Done():
+		}
+	})
+	log.Printf("session: client=%s seat=%s/%s reconnecting (grace %s)", clientID, c.team, c.seatID, l.grace)
+	l.broadcastState()
+}
+
+func (l *Lobby) expireGrace(msg GraceExpired) {
+	c, ok := l.clients[msg.ClientID]
+	if !ok || c.out != nil || c.graceGen != msg.Gen {
+		return // came back in time, or a stale fire
+	}
+	log.Printf("session: client=%s seat=%s/%s did not reconnect", msg.ClientID, c.team, c.seatID)
+	l.forget(msg.ClientID)
+	l.broadcastState()
+}
+
+func (l *Lobby) forget(clientID string) {
+	if c, ok := l.clients[clientID]; ok {
+		delete(l.sessions, c.session)
+		delete(l.clients, clientID)
+	}
+}
+
+// presence reports, for every seat on the roster, whether someone holds it.
+func (l *Lobby) presence() []types.SeatPresence {
+	var out []types.SeatPresence
+	for _, team := range []engine.Team{engine.TeamBlue, engine.TeamRed} {
+		for _, seat := range l.state.Seats[team] {
+			p := types.SeatPresence{Team: string(team), SeatID: seat.ID, Status: types.SeatVacant}
+			for _, c := range l.clients {
+				if c.team != team || c.seatID != seat.ID {
+					continue
+				}
+				if c.out != nil {
+					p.Status, p.LagMs = types.SeatConnected, c.lagNow().Milliseconds()
+					break
+				}
+				p.Status = types.SeatReconnecting
+			}
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func newSessionToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,136 @@
+package lobby
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+var ErrSpectator = errors.New("spectators can only watch")
+
+// Spectators watch without a seat and can never send commands. With
+// Rules.SpectatorDelaySec set, every snapshot is held back that long before
+// they see it, so nothing they stream reaches the players in time to matter.
+
+// releaseSpectators fires when the oldest held-back snapshot is due.
+type releaseSpectators struct{}
+
+func (releaseSpectators) isLobbyMsg() {}
+
+// delayed is a snapshot waiting out the spectator delay, with the patch that
+// gets there from the one before it.
+type delayed struct {
+	due   time.Time
+	msg   types.ServerMessage
+	patch types.ServerMessage
+}
+
+func (l *Lobby) spectatorDelay() time.Duration {
+	return time.Duration(l.state.Rules.SpectatorDelaySec) * time.Second
+}
+
+// holdForSpectators queues a snapshot (already cut down for spectators) that
+// was just sent to the players.
+func (l *Lobby) holdForSpectators(msg, patch types.ServerMessage) {
+	l.spectatorQueue = append(l.spectatorQueue, delayed{due: time.Now().Add(l.spectatorDelay()), msg: msg, patch: patch})
+	if len(l.spectatorQueue) == 1 {
+		l.armSpectatorTimer()
+	}
+}
+
+func (l *Lobby) armSpectatorTimer() {
+	if l.spectatorTimer != nil {
+		l.spectatorTimer.Stop()
+	}
+	if len(l.spectatorQueue) == 0 {
+		return
+	}
+	l.spectatorTimer = time.AfterFunc(time.Until(l.spectatorQueue[0].due), func() {
+		select {
+		case l.inbox <- releaseSpectators{}:
+		case <-l.ctx.Done():
+		}
+	})
+}
+
+// releaseDue sends every held-back snapshot whose delay is up, oldest first.
+func (l *Lobby) releaseDue() {
+	now := time.Now()
+	n := 0
+	for n < len(l.spectatorQueue) && !l.spectatorQueue[n].due.After(now) {
+		held := l.spectatorQueue[n]
+		l.spectatorView = held.msg
+		for id, c := range l.clients {
+			switch {
+			case c.spectator && c.patches:
+				l.sendTo(id, l.forSpectators(held.patch))
+			case c.spectator:
+				l.sendTo(id, l.forSpectators(held.msg))
+			}
+		}
+		n++
+	}
+	l.spectatorQueue = l.spectatorQueue[n:]
+	l.armSpectatorTimer()
+}
+
+// forSpectators stamps a held-back snapshot for sending now: its clock is
+// pushed back by the delay so the countdown matches the board they see.
+func (l *Lobby) forSpectators(msg types.ServerMessage) types.ServerMessage {
+	now := time.Now().UTC()
+	msg.ServerTime = now
+	if msg.Timer != nil {
+		timer := *msg.Timer
+		timer.Deadline = timer.Deadline.Add(l.spectatorDelay())
+		timer.RemainingMs = max(timer.Deadline.Sub(now).Milliseconds(), 0)
+		msg.Timer = &timer
+	}
+	return msg
+}
+
+// restoreSpectators picks delayed spectators up where they were before a
+// restart: the board as it stood one delay ago, with every later batch from
+// the log queued to come out when its own delay is up. Snapshots rebuilt from
+// the log carry no clock; the last one is the live snapshot.
+func (l *Lobby) restoreSpectators(now time.Time) {
+	live := l.hoversForSpectators(l.history[len(l.history)-1])
+	delay := l.spectatorDelay()
+	if delay <= 0 {
+		l.spectatorView = live
+		return
+	}
+	cutoff := now.Add(-delay)
+	var state engine.State
+	for i, entry := range l.log {
+		state = engine.Fold(state, entry.Event)
+		last := i == len(l.log)-1
+		if !last && l.log[i+1].Batch == entry.Batch {
+			continue // only whole batches were ever broadcast
+		}
+		msg := live
+		if !last {
+			past := state.Clone()
+			msg = l.hoversForSpectators(types.ServerMessage{Type: "StateSnapshot", Version: entry.Batch, State: &past})
+		}
+		if l.spectatorView.State == nil || !entry.At.After(cutoff) {
+			l.spectatorView = msg
+			continue
+		}
+		prev := l.spectatorView
+		if n := len(l.spectatorQueue); n > 0 {
+			prev = l.spectatorQueue[n-1].msg
+		}
+		l.spectatorQueue = append(l.spectatorQueue, delayed{due: entry.At.Add(delay), msg: msg, patch: toPatch(prev, msg)})
+	}
+	l.armSpectatorTimer()
+}
+
+// spectatorSnapshot is what a spectator should be shown right now.
+func (l *Lobby) spectatorSnapshot() types.ServerMessage {
+	if l.spectatorDelay() <= 0 {
+		return l.hoversForSpectators(l.snapshot(""))
+	}
+	return l.forSpectators(l.spectatorView)
+}
@@ -0,0 +1,46 @@
+package lobby
+
+import "github.com/DoyleJ11/lol-draft-backend/internal/engine"
+
+// Status is what the hub needs to decide when a lobby can go: whether anyone
+// is connected, whether the draft ever started, and whether the series is over.
+type Status struct {
+	Empty   bool // no open connections (seats held for a reconnect don't count)
+	Started bool // left pre-draft (a later game's pre-draft still counts)
+	Done    bool // series decided, or its last possible game finished
+}
+
+// WithStatusNotify calls fn with the lobby's status when it starts and again
+// whenever it changes. fn runs on the lobby goroutine, so it must not wait on
+// the lobby.
+func WithStatusNotify(fn func(Status)) Option {
+	return func(l *Lobby) { l.notify = fn }
+}
+
+func (l *Lobby) status() Status {
+	st := Status{Empty: true, Started: !l.state.PreDraft || l.state.Series.GameIndex > 0}
+	for _, c := range l.clients {
+		if c.out != nil {
+			st.Empty = false
+			break
+		}
+	}
+	_, st.Done = l.state.Series.Winner()
+	if sr := l.state.Series; !st.Done && sr.GameIndex >= sr.BestOf-1 && st.Started {
+		// No game can follow the last one, winner reported or not
+		_, finished := engine.CurrentStep(l.state)
+		st.Done = finished && !l.state.Trading
+	}
+	return st
+}
+
+// reportStatus tells the notify hook about a status change, if there was one.
+func (l *Lobby) reportStatus() {
+	if l.notify == nil {
+		return
+	}
+	if st := l.status(); !l.reported || st != l.lastStatus {
+		l.reported, l.lastStatus = true, st
+		l.notify(st)
+	}
+}
@@ -0,0 +1,66 @@
+package lobby
+
+import (
+	"slices"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+// historySize is how many broadcasts back a Sync can be served from patches.
+const historySize = 128
+
+// Sync asks to catch up from SinceVersion, the last version the client
+// holds, e.g. after it lost messages on a flaky connection or resumed its
+// session after being dropped.
+// Patch clients get every patch they missed, oldest first; anyone else, or a
+// version older than the history, gets one full snapshot tagged "Sync". A
+// client that is already current gets nothing.
+type Sync struct {
+	ClientID     string
+	SinceVersion int
+}
+
+func (Sync) isLobbyMsg() {}
+
+// remember keeps a broadcast snapshot (unfiltered) for later Syncs.
+func (l *Lobby) remember(msg types.ServerMessage) {
+	l.history = append(l.history, msg)
+	if len(l.history) > historySize {
+		l.history = slices.Delete(l.history, 0, len(l.history)-historySize)
+	}
+}
+
+func (l *Lobby) sync(msg Sync) {
+	c, ok := l.clients[msg.ClientID]
+	if !ok {
+		return
+	}
+	if msg.SinceVersion == l.version {
+		return
+	}
+	full := func() {
+		snap := l.snapshotFor(c)
+		snap.Reason = "Sync"
+		l.sendTo(msg.ClientID, snap)
+	}
+	// Delayed spectators run off their own queue; a snapshot is simplest
+	if !c.patches || c.spectator && l.spectatorDelay() > 0 {
+		full()
+		return
+	}
+
+	// The newest broadcast at that version is the state the client holds
+	from := -1
+	for i, h := range l.history {
+		if h.Version == msg.SinceVersion {
+			from = i
+		}
+	}
+	if from < 0 {
+		full()
+		return
+	}
+	for i := from + 1; i < len(l.history); i++ {
+		l.sendTo(msg.ClientID, toPatch(l.visibleTo(c, l.history[i-1]), l.visibleTo(c, l.history[i])))
+	}
+}
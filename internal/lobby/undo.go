@@ -0,0 +1,126 @@
+package lobby
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	"github.com/DoyleJ11/lol-draft-backend/internal/types"
+)
+
+var ErrNothingToUndo = errors.New("nothing to undo in this game")
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// UndoLastAction rolls back the last pick/ban (or skipped ban) of the current
+// game. Host only.
+type UndoLastAction struct{ ClientID string }
+
+func (UndoLastAction) isLobbyMsg() {}
+
+// RedoAction re-applies the most recently undone action, as long as no new
+// action has been taken since. Host only.
+type RedoAction struct{ ClientID string }
+
+func (RedoAction) isLobbyMsg() {}
+
+func (l *Lobby) undo(clientID string) {
+	if !l.isHost(clientID) {
+		l.sendTo(clientID, types.ServerMessage{Type: "Unauthorized", Error: ErrNotHost.Error()})
+		return
+	}
+
+	// Last batch that moved the cursor, without reaching back into an earlier game
+	target := 0
+scan:
+	for i := len(l.log) - 1; i >= 0; i-- {
+		switch l.log[i].Event.Type {
+		case engine.EvtNextGameStarted, engine.EvtSeriesCompleted, engine.EvtDraftStarted, engine.EvtLobbyCreated:
+			break scan
+		case engine.EvtTurnAdvanced:
+			target = l.log[i].Batch
+			break scan
+		}
+	}
+	if target == 0 {
+		l.sendTo(clientID, types.ServerMessage{Type: "Error", Error: ErrNothingToUndo.Error()})
+		return
+	}
+
+	// Everything after it belonged to the next turn (hovers, timebank, trades)
+	// and goes too, except the host's own pause/resume.
+	var undone []engine.Event
+	kept := l.log[:0:0]
+	from := 0 // first ordinal that changes
+	for _, entry := range l.log {
+		switch {
+		case entry.Batch == target:
+			if from == 0 {
+				from = entry.Ordinal
+			}
+			undone = append(undone, entry.Event)
+			continue
+		case entry.Batch > target && entry.Event.Type != engine.EvtDraftPaused && entry.Event.Type != engine.EvtDraftResumed:
+			continue
+		}
+		entry.Ordinal = len(kept) + 1
+		kept = append(kept, entry)
+	}
+	log.Printf("undo: dropping %v at cursor=%d", undone, l.state.Cursor)
+	l.redo = append(l.redo, redoCommand(undone))
+	l.log = kept
+
+	l.rebuild()
+	// Rewrite the stored tail: drop from the undone batch on, put back what was kept
+	l.persistRewrite(from, l.log[from-1:])
+
+	l.broadcast("Undo")
+}
+
+func (l *Lobby) redoAction(clientID string) {
+	if !l.isHost(clientID) {
+		l.sendTo(clientID, types.ServerMessage{Type: "Unauthorized", Error: ErrNotHost.Error()})
+		return
+	}
+	if len(l.redo) == 0 {
+		l.sendTo(clientID, types.ServerMessage{Type: "Error", Error: ErrNothingToRedo.Error()})
+		return
+	}
+
+	cmd := l.redo[len(l.redo)-1]
+	rest := l.redo[:len(l.redo)-1]
+	if err := l.runCommand(clientID, cmd, "Redo"); err != nil {
+		return // e.g. paused; it stays on the stack for another try
+	}
+	l.redo = rest // runCommand cleared the stack as for any new action; keep what's under it
+}
+
+// rebuild replays the log from scratch and restarts the clock for whatever
+// turn that lands on.
+func (l *Lobby) rebuild() {
+	l.state = engine.Reduce(engine.Events(l.log))
+
+	l.stopTurnTimer()
+	l.timerGen++ // a fire already in the inbox is now stale
+	if l.state.TimeBankActive {
+		l.bankStart = time.Now()
+	}
+	l.armTurnTimer() // no-op while paused or once the game is over
+}
+
+// redoCommand turns an undone batch back into the command that replays it.
+func redoCommand(batch []engine.Event) engine.Command {
+	for _, e := range batch {
+		switch e.Type {
+		case engine.EvtChampionPicked:
+			return engine.Command{Type: engine.CmdLockPick, Team: e.Team, SeatID: e.SeatID, ChampionID: e.ChampionID}
+		case engine.EvtChampionBanned:
+			return engine.Command{Type: engine.CmdBanChampion, Team: e.Team, ChampionID: e.ChampionID}
+		}
+	}
+	// A skipped ban. Time it out for a seat that never hovers, so a hover
+	// made since the undo doesn't turn into a ban.
+	return engine.Command{Type: engine.CmdTimeoutAdvance, SeatID: noHoverSeat}
+}
+
+const noHoverSeat = "-"
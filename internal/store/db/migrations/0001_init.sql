@@ -0,0 +1,63 @@
+CREATE TABLE users (
+    id            INTEGER PRIMARY KEY,
+    email         TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    display_name  TEXT NOT NULL,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE TABLE lobbies (
+    code            TEXT PRIMARY KEY,
+    host_user_id    INTEGER REFERENCES users(id),
+    format          TEXT NOT NULL,
+    series_type     INTEGER NOT NULL, -- best of
+    fearless        TEXT NOT NULL,
+    pick_timer_sec  INTEGER NOT NULL,
+    ban_timer_sec   INTEGER NOT NULL,
+    trade_timer_sec INTEGER NOT NULL,
+    time_bank_sec   INTEGER NOT NULL,
+    status          TEXT NOT NULL, -- open | drafting | done | closed (see store.Lobby*)
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE TABLE lobby_members (
+    lobby_code     TEXT NOT NULL REFERENCES lobbies(code),
+    user_id        INTEGER REFERENCES users(id),
+    seat_id        TEXT NOT NULL,
+    name           TEXT NOT NULL,
+    team           TEXT NOT NULL,
+    rank           TEXT,
+    role_primary   TEXT,
+    role_secondary TEXT,
+    PRIMARY KEY (lobby_code, team, seat_id)
+);
+
+CREATE TABLE series (
+    id           INTEGER PRIMARY KEY,
+    lobby_code   TEXT NOT NULL UNIQUE REFERENCES lobbies(code),
+    best_of      INTEGER NOT NULL,
+    fearless     TEXT NOT NULL,
+    created_at   TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE games (
+    id           INTEGER PRIMARY KEY,
+    series_id    INTEGER NOT NULL REFERENCES series(id),
+    game_index   INTEGER NOT NULL,
+    created_at   TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    UNIQUE (series_id, game_index)
+);
+
+CREATE TABLE draft_events (
+    id           INTEGER PRIMARY KEY,
+    lobby_code   TEXT NOT NULL REFERENCES lobbies(code),
+    game_id      INTEGER NOT NULL REFERENCES games(id),
+    ordinal      INTEGER NOT NULL,
+    batch        INTEGER NOT NULL,
+    type         TEXT NOT NULL,
+    payload_json TEXT NOT NULL,
+    at           TIMESTAMP NOT NULL,
+    UNIQUE (lobby_code, ordinal)
+);
@@ -0,0 +1,4 @@
+-- Key the lobby signs seat/host tokens with, so they survive a restart
+ALTER TABLE lobbies ADD COLUMN secret BLOB;
+
+CREATE INDEX lobbies_status ON lobbies (status);
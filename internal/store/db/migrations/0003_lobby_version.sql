@@ -0,0 +1,3 @@
+-- Last state version the lobby broadcast, so a restart doesn't hand clients
+-- versions they've already seen
+ALTER TABLE lobbies ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
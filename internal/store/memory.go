@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Memory is a Store that keeps everything in maps. It's the default when no
+// database is configured, and what tests use.
+type Memory struct {
+	mu      sync.Mutex
+	lobbies map[string]Lobby
+	events  map[string][]Event
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		lobbies: make(map[string]Lobby),
+		events:  make(map[string][]Event),
+	}
+}
+
+func (m *Memory) CreateLobby(ctx context.Context, lobby Lobby) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.lobbies[lobby.Code]; ok {
+		return ErrLobbyExists
+	}
+	m.lobbies[lobby.Code] = lobby
+	return nil
+}
+
+func (m *Memory) GetLobby(ctx context.Context, code string) (Lobby, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lobby, ok := m.lobbies[code]
+	if !ok {
+		return Lobby{}, ErrNotFound
+	}
+	return lobby, nil
+}
+
+func (m *Memory) ActiveLobbies(ctx context.Context) ([]Lobby, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Lobby
+	for _, lobby := range m.lobbies {
+		if lobby.Status != LobbyDone && lobby.Status != LobbyClosed {
+			out = append(out, lobby)
+		}
+	}
+	slices.SortFunc(out, func(a, b Lobby) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Code, b.Code)
+	})
+	return out, nil
+}
+
+func (m *Memory) AppendEvents(ctx context.Context, code string, events []Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lobby, ok := m.lobbies[code]
+	if !ok {
+		return ErrNotFound
+	}
+	next := len(m.events[code]) + 1
+	for i, e := range events {
+		if e.Ordinal != next+i {
+			return ErrOrdinal
+		}
+	}
+	for _, e := range events {
+		lobby.Status = statusAfter(lobby.Status, lobby.BestOf, e)
+	}
+	m.lobbies[code] = lobby
+	m.events[code] = append(m.events[code], events...)
+	return nil
+}
+
+func (m *Memory) TruncateEvents(ctx context.Context, code string, fromOrdinal int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lobby, ok := m.lobbies[code]
+	if !ok {
+		return ErrNotFound
+	}
+	if events := m.events[code]; fromOrdinal-1 < len(events) {
+		m.events[code] = events[:max(fromOrdinal-1, 0)]
+	}
+	if lobby.Status != LobbyClosed {
+		lobby.Status = statusOf(lobby.BestOf, m.events[code])
+		m.lobbies[code] = lobby
+	}
+	return nil
+}
+
+func (m *Memory) CloseLobby(ctx context.Context, code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lobby, ok := m.lobbies[code]
+	if !ok {
+		return ErrNotFound
+	}
+	lobby.Status = LobbyClosed
+	m.lobbies[code] = lobby
+	return nil
+}
+
+func (m *Memory) SetVersion(ctx context.Context, code string, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lobby, ok := m.lobbies[code]
+	if !ok {
+		return ErrNotFound
+	}
+	lobby.Version = version
+	m.lobbies[code] = lobby
+	return nil
+}
+
+func (m *Memory) Events(ctx context.Context, code string) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.lobbies[code]; !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(m.events[code]), nil
+}
+
+func (m *Memory) Close() error { return nil }
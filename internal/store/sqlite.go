@@ -0,0 +1,346 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+	_ "modernc.org/sqlite" // pure-Go driver, registers "sqlite"
+)
+
+//go:embed db/migrations/*.sql
+var migrations embed.FS
+
+// SQLite is the Store backed by a single SQLite file.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (creating if needed) the database at path and brings its
+// schema up to date. ":memory:" works for a throwaway database.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// One writer at a time is all SQLite does anyway; this also keeps
+	// ":memory:" to a single database.
+	db.SetMaxOpenConns(1)
+
+	s := &SQLite{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// migrate applies every embedded migration newer than the recorded version,
+// each in its own transaction. Files are applied in name order (0001_, 0002_...).
+func (s *SQLite) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY, applied_at TIMESTAMP NOT NULL)`); err != nil {
+		return err
+	}
+	names, err := fs.Glob(migrations, "db/migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], ".sql")
+		var exists int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		body, err := migrations.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UTC()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLite) CreateLobby(ctx context.Context, lobby Lobby) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM lobbies WHERE code = ?`, lobby.Code).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrLobbyExists
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO lobbies (code, format, series_type, fearless, pick_timer_sec, ban_timer_sec, trade_timer_sec, time_bank_sec, status, created_at, secret)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		lobby.Code, lobby.Format, lobby.BestOf, string(lobby.Fearless), lobby.PickTimerSec, lobby.BanTimerSec,
+		lobby.TradeTimerSec, lobby.TimeBankSec, lobby.Status, lobby.CreatedAt, lobby.Secret); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO series (lobby_code, best_of, fearless, created_at) VALUES (?, ?, ?, ?)`,
+		lobby.Code, lobby.BestOf, string(lobby.Fearless), lobby.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const lobbyColumns = `code, format, series_type, fearless, pick_timer_sec, ban_timer_sec, trade_timer_sec, time_bank_sec, status, created_at, secret, version`
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanLobby(row scanner) (Lobby, error) {
+	var l Lobby
+	var fearless string
+	err := row.Scan(&l.Code, &l.Format, &l.BestOf, &fearless, &l.PickTimerSec, &l.BanTimerSec, &l.TradeTimerSec, &l.TimeBankSec, &l.Status, &l.CreatedAt, &l.Secret, &l.Version)
+	l.Fearless = engine.FearlessMode(fearless)
+	return l, err
+}
+
+func (s *SQLite) GetLobby(ctx context.Context, code string) (Lobby, error) {
+	l, err := scanLobby(s.db.QueryRowContext(ctx, `SELECT `+lobbyColumns+` FROM lobbies WHERE code = ?`, code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Lobby{}, ErrNotFound
+	}
+	return l, err
+}
+
+func (s *SQLite) ActiveLobbies(ctx context.Context) ([]Lobby, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+lobbyColumns+` FROM lobbies WHERE status NOT IN (?, ?) ORDER BY created_at, code`, LobbyDone, LobbyClosed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Lobby
+	for rows.Next() {
+		l, err := scanLobby(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// AppendEvents writes the events and keeps the lobby's status, members,
+// games and series rows in step with them, all in one transaction.
+func (s *SQLite) AppendEvents(ctx context.Context, code string, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var seriesID int64
+	var status string
+	var bestOf int
+	err = tx.QueryRowContext(ctx, `SELECT s.id, l.status, s.best_of FROM series s JOIN lobbies l ON l.code = s.lobby_code WHERE l.code = ?`, code).Scan(&seriesID, &status, &bestOf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var last int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(ordinal), 0) FROM draft_events WHERE lobby_code = ?`, code).Scan(&last); err != nil {
+		return err
+	}
+
+	for i, e := range events {
+		if e.Ordinal != last+1+i {
+			return ErrOrdinal
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO games (series_id, game_index, created_at) VALUES (?, ?, ?)`, seriesID, e.Game, e.At); err != nil {
+			return err
+		}
+		var gameID int64
+		if err := tx.QueryRowContext(ctx, `SELECT id FROM games WHERE series_id = ? AND game_index = ?`, seriesID, e.Game).Scan(&gameID); err != nil {
+			return err
+		}
+		payload, err := json.Marshal(e.Event)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO draft_events (lobby_code, game_id, ordinal, batch, type, payload_json, at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			code, gameID, e.Ordinal, e.Batch, string(e.Event.Type), string(payload), e.At); err != nil {
+			return err
+		}
+
+		switch e.Event.Type {
+		case engine.EvtSeatJoined:
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO lobby_members (lobby_code, seat_id, name, team, role_primary) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (lobby_code, team, seat_id) DO UPDATE SET name = excluded.name, role_primary = excluded.role_primary`,
+				code, e.Event.SeatID, e.Event.Name, string(e.Event.Team), e.Event.Role); err != nil {
+				return err
+			}
+		case engine.EvtGameCompleted:
+			if _, err := tx.ExecContext(ctx, `UPDATE games SET completed_at = ? WHERE id = ?`, e.At, gameID); err != nil {
+				return err
+			}
+		case engine.EvtSeriesCompleted:
+			if _, err := tx.ExecContext(ctx, `UPDATE series SET completed_at = ? WHERE id = ?`, e.At, seriesID); err != nil {
+				return err
+			}
+		}
+		status = statusAfter(status, bestOf, e)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE lobbies SET status = ? WHERE code = ?`, status, code); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) TruncateEvents(ctx context.Context, code string, fromOrdinal int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM draft_events WHERE lobby_code = ? AND ordinal >= ?`, code, fromOrdinal); err != nil {
+		return err
+	}
+	// An undone last pick reopens its game
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE games SET completed_at = NULL
+		WHERE series_id = (SELECT id FROM series WHERE lobby_code = ?)
+		  AND completed_at IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM draft_events d WHERE d.game_id = games.id AND d.type = ?)`,
+		code, string(engine.EvtGameCompleted)); err != nil {
+		return err
+	}
+	// ...and with it maybe the lobby
+	status, err := s.statusFromLog(ctx, tx, code)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE lobbies SET status = ? WHERE code = ? AND status != ?`, status, code, LobbyClosed); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) CloseLobby(ctx context.Context, code string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE lobbies SET status = ? WHERE code = ?`, LobbyClosed, code)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *SQLite) SetVersion(ctx context.Context, code string, version int) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE lobbies SET version = ? WHERE code = ?`, version, code)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+// statusFromLog recomputes a lobby's status from the events that move it.
+func (s *SQLite) statusFromLog(ctx context.Context, tx *sql.Tx, code string) (string, error) {
+	var bestOf int
+	if err := tx.QueryRowContext(ctx, `SELECT best_of FROM series WHERE lobby_code = ?`, code).Scan(&bestOf); err != nil {
+		return "", err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT d.payload_json, g.game_index
+		FROM draft_events d JOIN games g ON g.id = d.game_id
+		WHERE d.lobby_code = ? AND d.type IN (?, ?, ?, ?, ?) ORDER BY d.ordinal`,
+		code, string(engine.EvtLobbyCreated), string(engine.EvtDraftStarted), string(engine.EvtNextGameStarted),
+		string(engine.EvtGameCompleted), string(engine.EvtSeriesCompleted))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var payload string
+		var e Event
+		if err := rows.Scan(&payload, &e.Game); err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal([]byte(payload), &e.Event); err != nil {
+			return "", err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return statusOf(bestOf, events), nil
+}
+
+func (s *SQLite) Events(ctx context.Context, code string) ([]Event, error) {
+	if _, err := s.GetLobby(ctx, code); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.ordinal, d.batch, d.payload_json, d.at, g.game_index
+		FROM draft_events d JOIN games g ON g.id = d.game_id
+		WHERE d.lobby_code = ? ORDER BY d.ordinal`, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Event
+	for rows.Next() {
+		var e Event
+		var payload string
+		if err := rows.Scan(&e.Ordinal, &e.Batch, &payload, &e.At, &e.Game); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(payload), &e.Event); err != nil {
+			return nil, fmt.Errorf("event %d: %w", e.Ordinal, err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLite) Close() error { return s.db.Close() }
@@ -0,0 +1,136 @@
+// Package store persists lobbies and their draft event logs.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+)
+
+var ErrNotFound = errors.New("lobby not found")
+var ErrLobbyExists = errors.New("lobby already exists")
+var ErrOrdinal = errors.New("event ordinal out of sequence")
+
+const (
+	LobbyOpen     = "open"     // seats filling, draft not started
+	LobbyDrafting = "drafting" // a game of the series is running
+	LobbyDone     = "done"     // series decided
+	LobbyClosed   = "closed"   // shut down by the server before that, e.g. idle
+)
+
+// Store is where lobbies and their event logs live beyond the process. Each
+// lobby writes from a goroutine of its own, in order, so implementations must
+// be safe to share between lobbies.
+type Store interface {
+	CreateLobby(ctx context.Context, lobby Lobby) error
+	GetLobby(ctx context.Context, code string) (Lobby, error)
+	// ActiveLobbies lists every lobby that is neither done nor closed, oldest
+	// first: what a restarted server brings back.
+	ActiveLobbies(ctx context.Context) ([]Lobby, error)
+	// CloseLobby marks a lobby closed for good; its log is kept.
+	CloseLobby(ctx context.Context, code string) error
+	// SetVersion records the last state version the lobby sent its clients,
+	// so a restored lobby carries on above it.
+	SetVersion(ctx context.Context, code string, version int) error
+
+	// AppendEvents adds entries to the end of a lobby's log. Ordinals must
+	// continue on from the last stored one.
+	AppendEvents(ctx context.Context, code string, events []Event) error
+	// TruncateEvents drops every event at fromOrdinal and after (undo).
+	TruncateEvents(ctx context.Context, code string, fromOrdinal int) error
+	// Events returns a lobby's whole log in ordinal order.
+	Events(ctx context.Context, code string) ([]Event, error)
+
+	Close() error
+}
+
+// Lobby is a lobby's settings as of creation, plus where it is now.
+type Lobby struct {
+	Code          string
+	Status        string
+	Format        string
+	BestOf        int
+	Fearless      engine.FearlessMode
+	PickTimerSec  int
+	BanTimerSec   int
+	TradeTimerSec int
+	TimeBankSec   int
+	CreatedAt     time.Time
+	Secret        []byte // the lobby's token signing key
+	Version       int    // last state version broadcast (see SetVersion)
+}
+
+// LobbyFromState describes a lobby starting from s.
+func LobbyFromState(code string, s engine.State) Lobby {
+	fearless := s.Rules.Fearless
+	if fearless == "" {
+		fearless = engine.FearlessOff
+	}
+	status := LobbyOpen
+	if !s.PreDraft {
+		status = LobbyDrafting
+	}
+	return Lobby{
+		Code:          code,
+		Status:        status,
+		Format:        s.Rules.ActiveFormat().ID,
+		BestOf:        s.Series.BestOf,
+		Fearless:      fearless,
+		PickTimerSec:  s.Rules.PickTimerSec,
+		BanTimerSec:   s.Rules.BanTimerSec,
+		TradeTimerSec: s.Rules.TradeTimerSec,
+		TimeBankSec:   s.Rules.TimeBankSec,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// Event is a lobby log entry plus which game of the series it belongs to.
+type Event struct {
+	engine.LogEntry
+	Game int // Series.GameIndex once the event's command was applied
+}
+
+// LogEntries strips stored events back down to the lobby's log.
+func LogEntries(events []Event) []engine.LogEntry {
+	out := make([]engine.LogEntry, len(events))
+	for i, e := range events {
+		out[i] = e.LogEntry
+	}
+	return out
+}
+
+// statusAfter moves a lobby's status along as its events come in. The last
+// game a series can have finishing ends the lobby too: nobody needs to report
+// its winner for there to be nothing left to draft.
+func statusAfter(status string, bestOf int, e Event) string {
+	if status == LobbyClosed {
+		return status
+	}
+	switch e.Event.Type {
+	case engine.EvtLobbyCreated:
+		if e.Event.Initial != nil && !e.Event.Initial.PreDraft {
+			return LobbyDrafting
+		}
+	case engine.EvtDraftStarted, engine.EvtNextGameStarted:
+		return LobbyDrafting
+	case engine.EvtGameCompleted:
+		if e.Game >= bestOf-1 {
+			return LobbyDone
+		}
+	case engine.EvtSeriesCompleted:
+		return LobbyDone
+	}
+	return status
+}
+
+// statusOf works a lobby's status out from its whole log, e.g. after an undo
+// took back the event that ended it.
+func statusOf(bestOf int, events []Event) string {
+	status := LobbyOpen
+	for _, e := range events {
+		status = statusAfter(status, bestOf, e)
+	}
+	return status
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+)
+
+// Both implementations must behave the same; every test runs against each.
+func eachStore(t *testing.T, fn func(t *testing.T, st Store)) {
+	t.Run("memory", func(t *testing.T) { fn(t, NewMemory()) })
+	t.Run("sqlite", func(t *testing.T) {
+		st, err := OpenSQLite(filepath.Join(t.TempDir(), "draft.db"))
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		defer st.Close()
+		fn(t, st)
+	})
+}
+
+func entries(from int, batch int, events ...engine.Event) []Event {
+	out := make([]Event, len(events))
+	for i, e := range events {
+		out[i] = Event{LogEntry: engine.LogEntry{Ordinal: from + i, Batch: batch, At: time.Now().UTC(), Event: e}}
+	}
+	return out
+}
+
+func TestStore_LobbyAndEventsRoundTrip(t *testing.T) {
+	eachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		initial := engine.NewPreDraftState()
+		initial.Seed = 7
+		initial.Series = engine.NewSeries(3)
+		initial.Rules.Fearless = engine.FearlessHard
+
+		if err := st.CreateLobby(ctx, LobbyFromState("ABC123", initial)); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if err := st.CreateLobby(ctx, LobbyFromState("ABC123", initial)); !errors.Is(err, ErrLobbyExists) {
+			t.Fatalf("want ErrLobbyExists, got %v", err)
+		}
+		if _, err := st.GetLobby(ctx, "NOPE00"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("want ErrNotFound, got %v", err)
+		}
+
+		log := entries(1, 1, engine.Event{Type: engine.EvtLobbyCreated, Initial: &initial})
+		log = append(log, entries(2, 2, engine.Event{Type: engine.EvtSeatJoined, Team: engine.TeamBlue, SeatID: "b1", Name: "Jack", Role: "mid"})...)
+		log = append(log, entries(3, 3, engine.Event{Type: engine.EvtSeatJoined, Team: engine.TeamRed, SeatID: "r1"})...)
+		log = append(log, entries(4, 4,
+			engine.Event{Type: engine.EvtTeamReady, Team: engine.TeamBlue},
+			engine.Event{Type: engine.EvtTeamReady, Team: engine.TeamRed},
+		)...)
+		log = append(log, entries(6, 5, engine.Event{Type: engine.EvtDraftStarted}, engine.Event{Type: engine.EvtTimerStarted})...)
+		log = append(log, entries(8, 6, engine.Event{Type: engine.EvtChampionBanned, Team: engine.TeamBlue, ChampionID: 1}, engine.Event{Type: engine.EvtTurnAdvanced})...)
+		for _, batch := range [][]Event{log[:1], log[1:3], log[3:7], log[7:]} {
+			if err := st.AppendEvents(ctx, "ABC123", batch); err != nil {
+				t.Fatalf("append: %v", err)
+			}
+		}
+		if err := st.AppendEvents(ctx, "ABC123", entries(99, 7, engine.Event{Type: engine.EvtTurnAdvanced})); !errors.Is(err, ErrOrdinal) {
+			t.Fatalf("want ErrOrdinal for a gap, got %v", err)
+		}
+
+		lobby, err := st.GetLobby(ctx, "ABC123")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if lobby.Status != LobbyDrafting || lobby.BestOf != 3 || lobby.Fearless != engine.FearlessHard || lobby.Format != "tournament" {
+			t.Fatalf("unexpected lobby row %+v", lobby)
+		}
+
+		stored, err := st.Events(ctx, "ABC123")
+		if err != nil {
+			t.Fatalf("events: %v", err)
+		}
+		if len(stored) != len(log) || stored[8].Ordinal != 9 || stored[8].Batch != 6 {
+			t.Fatalf("want %d events in order, got %+v", len(log), stored)
+		}
+		want := engine.Reduce(engine.Events(LogEntries(log)))
+		if got := engine.Reduce(engine.Events(LogEntries(stored))); !reflect.DeepEqual(got, want) {
+			t.Fatalf("replayed state differs.\n got: %#v\nwant: %#v", got, want)
+		}
+
+		// Undo the ban
+		if err := st.TruncateEvents(ctx, "ABC123", 8); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		if stored, _ := st.Events(ctx, "ABC123"); len(stored) != 7 {
+			t.Fatalf("want 7 events after truncate, got %d", len(stored))
+		}
+		if err := st.AppendEvents(ctx, "ABC123", entries(8, 7, engine.Event{Type: engine.EvtChampionBanned, Team: engine.TeamBlue, ChampionID: 2})); err != nil {
+			t.Fatalf("append after truncate: %v", err)
+		}
+	})
+}
+
+func TestSQLite_MigrationsAreIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "draft.db")
+	for range 2 {
+		st, err := OpenSQLite(path)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		st.Close()
+	}
+}
+
+func TestStore_ActiveLobbiesSkipsFinishedSeries(t *testing.T) {
+	eachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		initial := engine.NewEmptyState()
+		for i, code := range []string{"LIVE01", "DONE01"} {
+			rec := LobbyFromState(code, initial)
+			rec.CreatedAt = rec.CreatedAt.Add(time.Duration(i) * time.Second)
+			rec.Secret = []byte("key-" + code)
+			if err := st.CreateLobby(ctx, rec); err != nil {
+				t.Fatalf("create %s: %v", code, err)
+			}
+			if err := st.AppendEvents(ctx, code, entries(1, 1, engine.Event{Type: engine.EvtLobbyCreated, Initial: &initial})); err != nil {
+				t.Fatalf("append %s: %v", code, err)
+			}
+		}
+		if err := st.AppendEvents(ctx, "DONE01", entries(2, 2, engine.Event{Type: engine.EvtSeriesCompleted})); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+
+		active, err := st.ActiveLobbies(ctx)
+		if err != nil {
+			t.Fatalf("active: %v", err)
+		}
+		if len(active) != 1 || active[0].Code != "LIVE01" || string(active[0].Secret) != "key-LIVE01" {
+			t.Fatalf("want just LIVE01 with its secret, got %+v", active)
+		}
+	})
+}
+
+func TestStore_LastGameCompletedEndsLobby(t *testing.T) {
+	eachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		initial := engine.NewEmptyState() // Bo1, already drafting
+		if err := st.CreateLobby(ctx, LobbyFromState("BO1DONE", initial)); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		log := entries(1, 1, engine.Event{Type: engine.EvtLobbyCreated, Initial: &initial})
+		log = append(log, entries(2, 2, engine.Event{Type: engine.EvtChampionPicked, Team: engine.TeamRed, ChampionID: 1}, engine.Event{Type: engine.EvtGameCompleted})...)
+		if err := st.AppendEvents(ctx, "BO1DONE", log); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		status := func() string {
+			lobby, err := st.GetLobby(ctx, "BO1DONE")
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			return lobby.Status
+		}
+		// Nobody reported the winner, but there's no game after this one
+		if got := status(); got != LobbyDone {
+			t.Fatalf("want %s, got %s", LobbyDone, got)
+		}
+
+		// Undoing the last pick reopens it
+		if err := st.TruncateEvents(ctx, "BO1DONE", 2); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		if got := status(); got != LobbyDrafting {
+			t.Fatalf("after undo: want %s, got %s", LobbyDrafting, got)
+		}
+	})
+}
+
+func TestStore_ClosedLobbiesAreNotActive(t *testing.T) {
+	eachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		initial := engine.NewEmptyState()
+		if err := st.CreateLobby(ctx, LobbyFromState("GONE01", initial)); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if err := st.CloseLobby(ctx, "GONE01"); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+		if err := st.CloseLobby(ctx, "NOPE00"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("want ErrNotFound, got %v", err)
+		}
+
+		// A write that was still queued when it closed doesn't reopen it
+		if err := st.AppendEvents(ctx, "GONE01", entries(1, 1, engine.Event{Type: engine.EvtLobbyCreated, Initial: &initial})); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		if active, err := st.ActiveLobbies(ctx); err != nil || len(active) != 0 {
+			t.Fatalf("want no active lobbies, got %+v (%v)", active, err)
+		}
+	})
+}
+
+func TestStore_SetVersion(t *testing.T) {
+	eachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		if err := st.CreateLobby(ctx, LobbyFromState("VERS01", engine.NewEmptyState())); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if err := st.SetVersion(ctx, "VERS01", 42); err != nil {
+			t.Fatalf("set version: %v", err)
+		}
+		if got, err := st.GetLobby(ctx, "VERS01"); err != nil || got.Version != 42 {
+			t.Fatalf("want version 42, got %d (%v)", got.Version, err)
+		}
+		if err := st.SetVersion(ctx, "NOPE00", 1); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("want ErrNotFound, got %v", err)
+		}
+	})
+}
@@ -1,17 +1,84 @@
 package types
 
-import "github.com/DoyleJ11/lol-draft-backend/internal/engine"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/DoyleJ11/lol-draft-backend/internal/engine"
+)
 
 type ClientMessage struct {
 	Type       string `json:"type"`
 	Team       string `json:"team,omitempty"`
 	SeatID     string `json:"seat_id,omitempty"`
 	ChampionID int    `json:"champion_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Role       string `json:"role,omitempty"`
+
+	TargetSeatID string `json:"target_seat_id,omitempty"` // trades
+	Token        string `json:"token,omitempty"`          // ClaimSeat: reclaim a seat; ClaimHost: host token
+	SinceVersion int    `json:"since_version,omitempty"`  // Sync: the last version you hold
 }
 
 type ServerMessage struct {
-	Type    string        `json:"type"` // "StateSnapshot" | "Error"
+	Type    string        `json:"type"` // "StateSnapshot" | "StatePatch" | "Error" | "Unauthorized" | "SeatClaimed" | "HostClaimed" | "ResultReported" | "ServerShuttingDown" | "Lagging"
 	Version int           `json:"version,omitempty"`
 	State   *engine.State `json:"state,omitempty"`
 	Error   string        `json:"error,omitempty"`
+	Seat    *SeatClaim    `json:"seat,omitempty"`
+	Winner  string        `json:"winner,omitempty"` // ResultReported: a captain says this team won; the other captain confirms with the same StartNextGame
+
+	Session  *Session       `json:"session,omitempty"`  // on the first snapshot after joining/resuming
+	Presence []SeatPresence `json:"presence,omitempty"` // on snapshots: who is sitting where
+
+	// On snapshots: the running clock (nil when nothing is ticking) and the
+	// server's time when the message was built, for skew correction
+	Timer      *TurnTimer `json:"timer,omitempty"`
+	ServerTime time.Time  `json:"server_time,omitzero"`
+	Reason     string     `json:"reason,omitempty"` // on snapshots sent for a reason other than a command, e.g. "Undo" | "Redo"
+
+	// On StatePatch (instead of State): the version this applies on top of
+	// (absent = 0) and the top-level State fields that changed, each replaced
+	// whole. On a gap, send RequestSnapshot.
+	BaseVersion int                        `json:"base_version,omitempty"`
+	Patch       map[string]json.RawMessage `json:"patch,omitempty"`
+}
+
+// TurnTimer is the lobby's authoritative deadline for the current turn (or
+// timebank, or trade window). Gen matches the lobby's timer generation and
+// changes every time the clock is re-armed.
+type TurnTimer struct {
+	Deadline    time.Time `json:"deadline"` // UTC
+	RemainingMs int64     `json:"remaining_ms"`
+	Gen         int       `json:"gen"`
+	TimeBank    bool      `json:"time_bank,omitempty"` // running on the team's reserve
+}
+
+// Session identifies this client to the lobby. Reconnect with
+// /ws?code=...&session=<Token> to get the same client (and seat) back.
+type Session struct {
+	ClientID string `json:"client_id"`
+	Token    string `json:"token"`
+	Resumed  bool   `json:"resumed,omitempty"`
+}
+
+const (
+	SeatConnected    = "connected"
+	SeatReconnecting = "reconnecting"
+	SeatVacant       = "vacant"
+)
+
+type SeatPresence struct {
+	Team   string `json:"team"`
+	SeatID string `json:"seat_id"`
+	Status string `json:"status"`           // SeatConnected | SeatReconnecting | SeatVacant
+	LagMs  int64  `json:"lag_ms,omitempty"` // how far behind the server this seat's connection is running
+}
+
+// SeatClaim is returned on a successful ClaimSeat. Keep the token: sending it
+// with a later ClaimSeat gets the same seat back.
+type SeatClaim struct {
+	Team   string `json:"team"`
+	SeatID string `json:"seat_id"`
+	Token  string `json:"token"`
 }
@@ -14,6 +14,12 @@ import (
 	"github.com/coder/websocket"
 )
 
+// How often each socket is pinged, and how long the pong may take.
+const (
+	pingEvery   = 15 * time.Second
+	pingTimeout = 10 * time.Second
+)
+
 func Handler(h *hub.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
@@ -22,6 +28,20 @@ func Handler(h *hub.Hub) http.HandlerFunc {
 			return
 		}
 
+		// ?role=spectator watches on the lobby's spectator delay and can't act
+		var spectator bool
+		switch r.URL.Query().Get("role") {
+		case "", "player":
+		case "spectator":
+			spectator = true
+		default:
+			http.Error(w, "role must be player or spectator", http.StatusBadRequest)
+			return
+		}
+
+		// ?patches=1: StatePatch messages after the first snapshot
+		patches := r.URL.Query().Get("patches") == "1"
+
 		reply := make(chan *lobby.Lobby, 1)
 		h.Inbox() <- hub.GetLobby{Code: code, Reply: reply}
 		lb := <-reply
@@ -30,6 +50,14 @@ func Handler(h *hub.Hub) http.HandlerFunc {
 			return
 		}
 
+		// Counted before the hijack so a shutdown can't miss it
+		done, ok := h.TrackConn()
+		if !ok {
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		defer done()
+
 		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
 			// In dev ONLY, you can loosen origin checks:
 			OriginPatterns: []string{"http://localhost:*", "http://127.0.0.1:*"},
@@ -42,8 +70,34 @@ func Handler(h *hub.Hub) http.HandlerFunc {
 		out := make(chan types.ServerMessage, 8)
 		clientID := randID(6) // Implement simple rand id
 
-		lb.Inbox() <- lobby.Join{ClientID: clientID, Outbox: out}
-		defer func() { lb.Inbox() <- lobby.Leave{ClientID: clientID} }()
+		// The lobby can go away under us at any point (shut down or
+		// collected), and then nobody reads its inbox. False means it's gone
+		// and this socket is done.
+		send := func(m lobby.Msg) bool {
+			select {
+			case lb.Inbox() <- m:
+				return true
+			case <-lb.Done():
+				return false
+			}
+		}
+
+		// ?session=<token> from an earlier snapshot picks the old client (and
+		// seat) back up; unknown/expired tokens just get a fresh join.
+		if session := r.URL.Query().Get("session"); session != "" {
+			idReply := make(chan string, 1)
+			if !send(lobby.Resume{Session: session, ClientID: clientID, Outbox: out, Reply: idReply, Spectator: spectator, Patches: patches}) {
+				return
+			}
+			select {
+			case clientID = <-idReply:
+			case <-lb.Done():
+				return
+			}
+		} else if !send(lobby.Join{ClientID: clientID, Outbox: out, Spectator: spectator, Patches: patches}) {
+			return
+		}
+		defer send(lobby.Leave{ClientID: clientID, Outbox: out})
 
 		// Writer goroutine
 		writeCtx, writeCancel := context.WithCancel(r.Context())
@@ -55,13 +109,36 @@ func Handler(h *hub.Hub) http.HandlerFunc {
 				_ = conn.Write(ctx, websocket.MessageText, payload)
 				cancel()
 			}
+			// The lobby closed our outbox: it shut down, or dropped us as
+			// too slow. Either way this socket is done; that also ends the
+			// reader loop below.
+			_ = conn.Close(websocket.StatusGoingAway, "lobby closed")
+		}()
+
+		// Keepalive: a socket that stops answering pings is dead. One that's
+		// just quiet is fine; spectators never send anything.
+		go func() {
+			ticker := time.NewTicker(pingEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-writeCtx.Done():
+					return
+				case <-ticker.C:
+				}
+				ctx, cancel := context.WithTimeout(writeCtx, pingTimeout)
+				err := conn.Ping(ctx)
+				cancel()
+				if err != nil {
+					_ = conn.CloseNow() // ends the reader loop below
+					return
+				}
+			}
 		}()
 
 		// Reader loop
 		for {
-			ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-			_, data, err := conn.Read(ctx)
-			cancel()
+			_, data, err := conn.Read(r.Context())
 			if err != nil {
 				// Treat clean close/going-away as normal:
 				switch websocket.CloseStatus(err) {
@@ -79,18 +156,70 @@ func Handler(h *hub.Hub) http.HandlerFunc {
 				continue
 			}
 
+			if cm.Type == "ClaimSeat" {
+				team, ok := parseTeam(cm.Team)
+				if !ok {
+					_ = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"Error","error":"bad team"}`))
+					continue
+				}
+				if !send(lobby.ClaimSeat{ClientID: clientID, Team: team, SeatID: cm.SeatID, Name: cm.Name, Role: cm.Role, Token: cm.Token}) {
+					return
+				}
+				continue
+			}
+
+			switch cm.Type {
+			case "ClaimHost":
+				if !send(lobby.ClaimHost{ClientID: clientID, Token: cm.Token}) {
+					return
+				}
+				continue
+			case "UndoLastAction":
+				if !send(lobby.UndoLastAction{ClientID: clientID}) {
+					return
+				}
+				continue
+			case "RedoAction":
+				if !send(lobby.RedoAction{ClientID: clientID}) {
+					return
+				}
+				continue
+			case "RequestSnapshot":
+				if !send(lobby.RequestSnapshot{ClientID: clientID}) {
+					return
+				}
+				continue
+			case "Sync":
+				if !send(lobby.Sync{ClientID: clientID, SinceVersion: cm.SinceVersion}) {
+					return
+				}
+				continue
+			}
+
 			cmd, ok := toEngineCommand(cm)
 			if !ok {
 				_ = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"Error","error":"unknown type"}`))
 				continue
 			}
 
-			lb.Inbox() <- lobby.FromClient{ClientID: clientID, Cmd: cmd}
+			if !send(lobby.FromClient{ClientID: clientID, Cmd: cmd}) {
+				return
+			}
 		}
 	}
 }
 
 func toEngineCommand(m types.ClientMessage) (engine.Command, bool) {
+	// Not tied to a side
+	switch m.Type {
+	case "StartGame":
+		return engine.Command{Type: engine.CmdStartGame}, true
+	case "PauseDraft":
+		return engine.Command{Type: engine.CmdPauseDraft}, true
+	case "ResumeDraft":
+		return engine.Command{Type: engine.CmdResumeDraft}, true
+	}
+
 	team, ok := parseTeam(m.Team)
 	if !ok {
 		return engine.Command{}, false
@@ -103,6 +232,19 @@ func toEngineCommand(m types.ClientMessage) (engine.Command, bool) {
 		return engine.Command{Type: engine.CmdBanChampion, Team: team, ChampionID: m.ChampionID}, true
 	case "HoverChampion":
 		return engine.Command{Type: engine.CmdHoverChampion, Team: team, SeatID: m.SeatID, ChampionID: m.ChampionID}, true
+	case "Ready":
+		return engine.Command{Type: engine.CmdReady, Team: team, SeatID: m.SeatID}, true
+	case "Unready":
+		return engine.Command{Type: engine.CmdUnready, Team: team, SeatID: m.SeatID}, true
+	case "ProposeTrade":
+		return engine.Command{Type: engine.CmdProposeTrade, Team: team, SeatID: m.SeatID, TargetSeatID: m.TargetSeatID}, true
+	case "AcceptTrade":
+		return engine.Command{Type: engine.CmdAcceptTrade, Team: team, SeatID: m.SeatID, TargetSeatID: m.TargetSeatID}, true
+	case "DeclineTrade":
+		return engine.Command{Type: engine.CmdDeclineTrade, Team: team, SeatID: m.SeatID, TargetSeatID: m.TargetSeatID}, true
+	case "StartNextGame":
+		// team = winner of the game that just finished
+		return engine.Command{Type: engine.CmdStartNextGame, Team: team}, true
 	default:
 		return engine.Command{}, false
 	}